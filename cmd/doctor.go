@@ -0,0 +1,44 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/cli"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/spf13/cobra"
+)
+
+func doctorCommand(options *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that interruption experiments can run in the account and region, and against --instance-ids if passed",
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx := context.Background()
+			cfg, err := loadConfig(ctx, *options)
+			if err != nil {
+				fmt.Printf("❌ %s\n", err)
+				os.Exit(1)
+			}
+			checks := itn.New(cfg).Preflight(ctx, options.instanceIDs)
+			fmt.Print(cli.ChecksSummary(checks))
+			if checks.Err() != nil {
+				os.Exit(1)
+			}
+		},
+	}
+}
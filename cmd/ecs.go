@@ -0,0 +1,44 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/samber/lo"
+)
+
+// ecsTargets targets the instances backing the container instances of --ecs-cluster, only those of
+// --ecs-capacity-provider if passed. It returns the watchers that verify the container instances are drained if
+// --verify-drain was passed.
+func ecsTargets(ctx context.Context, cfg aws.Config, options *Options) ([]itn.Watcher, error) {
+	interrupter := itn.New(cfg)
+	instances, err := interrupter.ContainerInstances(ctx, options.ecsCluster, options.ecsCapacityProvider)
+	if err != nil {
+		return nil, err
+	}
+	if options.output == outputText {
+		for _, instance := range instances {
+			fmt.Printf("📦 %s is %s\n", instance.ARN, instance.InstanceID)
+		}
+	}
+	options.instanceIDs = lo.Map(instances, func(instance itn.ContainerInstance, _ int) string { return instance.InstanceID })
+	if !options.verifyDrain {
+		return nil, nil
+	}
+	return []itn.Watcher{interrupter.ECSDrainWatcher(ctx, options.ecsCluster, instances, itn.PollOptions{})}, nil
+}
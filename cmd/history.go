@@ -0,0 +1,88 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/cli"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/history"
+	"github.com/spf13/cobra"
+)
+
+func historyCommand() *cobra.Command {
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the experiments started from this machine",
+	}
+	historyCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the experiments in the history, most recent first",
+		Run: func(cmd *cobra.Command, _ []string) {
+			records := listHistory()
+			if len(records) == 0 {
+				fmt.Println("No experiments in the history yet")
+				return
+			}
+			fmt.Print(cli.HistoryTable(records))
+		},
+	})
+	historyCmd.AddCommand(&cobra.Command{
+		Use:   "show <experiment-id>",
+		Short: "Show an experiment in the history along with its event log",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			record, err := historyStore().Get(args[0])
+			if err != nil {
+				fmt.Printf("❌ %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(cli.HistoryDetail(record))
+		},
+	})
+	var format string
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the history for analysis elsewhere",
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := history.Export(os.Stdout, listHistory(), format); err != nil {
+				fmt.Printf("❌ %s\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+	exportCmd.Flags().StringVar(&format, "format", history.FormatJSON, fmt.Sprintf("export format, one of %v", history.Formats))
+	historyCmd.AddCommand(exportCmd)
+	return historyCmd
+}
+
+// historyStore opens the history at its default path
+func historyStore() *history.Store {
+	path, err := history.DefaultPath()
+	if err != nil {
+		fmt.Printf("❌ %s\n", err)
+		os.Exit(1)
+	}
+	return history.NewStore(path)
+}
+
+func listHistory() []history.Record {
+	records, err := historyStore().List()
+	if err != nil {
+		fmt.Printf("❌ %s\n", err)
+		os.Exit(1)
+	}
+	return records
+}
@@ -0,0 +1,222 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/cli"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+)
+
+// interrupt interrupts instances in the default region, across regions, or across accounts depending on the options
+func interrupt(ctx context.Context, cfg aws.Config, options Options) ([]itn.Run, <-chan itn.Event, error) {
+	multiAccount := len(options.accounts) > 0 || options.organizationalUnit != ""
+	byRegion, unqualified := itn.ParseInstanceSelectors(options.instanceIDs)
+	// without --regions, only instance IDs qualified with their region can do without the default region
+	if len(options.regions) == 0 && cfg.Region == "" && (multiAccount || len(byRegion) == 0 || len(unqualified) > 0) {
+		return nil, nil, errors.New("no region specified, pass --region or --regions, or set a default region in the AWS configuration")
+	}
+	if multiAccount {
+		return interruptAccounts(ctx, cfg, options)
+	}
+	if len(options.regions) > 0 || len(byRegion) > 0 {
+		return interruptRegions(ctx, cfg, options, byRegion, unqualified)
+	}
+	return interruptRegion(ctx, cfg, options)
+}
+
+// interruptRegion interrupts instances in the default region
+func interruptRegion(ctx context.Context, cfg aws.Config, options Options) ([]itn.Run, <-chan itn.Event, error) {
+	interrupter := itn.New(cfg)
+	instanceIDs := options.instanceIDs
+	if len(options.tags) > 0 {
+		instances, err := interrupter.SpotInstancesWithTags(ctx, options.tags)
+		if err != nil {
+			return nil, nil, err
+		}
+		instanceIDs = lo.Map(instances, func(instance ec2types.Instance, _ int) string { return *instance.InstanceId })
+	}
+	if options.skipInvalid {
+		valid, err := interrupter.Validate(ctx, instanceIDs)
+		if err := skipInvalid(err); err != nil {
+			return nil, nil, err
+		}
+		instanceIDs = valid
+	}
+	if options.preflight {
+		checks := interrupter.Preflight(ctx, instanceIDs)
+		fmt.Print(cli.ChecksSummary(checks))
+		if err := checks.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := interrupter.CheckGuardrails(ctx, instanceIDs, options.guardrails); err != nil {
+		return nil, nil, err
+	}
+	if !options.yes {
+		accountID, err := interrupter.AccountID(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := confirm(map[string]map[string][]string{accountID: {interrupter.Region(): instanceIDs}}); err != nil {
+			return nil, nil, err
+		}
+	}
+	experiment, events, err := interrupter.Interrupt(ctx, instanceIDs, options.interruptOptions())
+	if err != nil {
+		return nil, nil, err
+	}
+	return []itn.Run{{Region: interrupter.Region(), Experiment: experiment, InstanceIDs: instanceIDs}}, events, nil
+}
+
+// interruptRegions interrupts instances across regions. Instance IDs qualified with a region are interrupted in that
+// region, and unqualified instance IDs are located in --regions, or fall back to the default region.
+func interruptRegions(ctx context.Context, cfg aws.Config, options Options, byRegion map[string][]string, unqualified []string) ([]itn.Run, <-chan itn.Event, error) {
+	defaultRegion := cfg.Region
+	regions := lo.Uniq(append(lo.Keys(byRegion), options.regions...))
+	if len(options.regions) == 0 && len(unqualified) > 0 {
+		regions = lo.Uniq(append(regions, defaultRegion))
+	}
+	multiRegion := itn.NewMultiRegion(cfg, regions)
+	if len(options.tags) > 0 {
+		var err error
+		if byRegion, err = multiRegion.SpotInstancesWithTags(ctx, options.tags); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(unqualified) > 0 {
+		if len(options.regions) == 0 {
+			byRegion[defaultRegion] = append(byRegion[defaultRegion], unqualified...)
+		} else {
+			located, err := multiRegion.Locate(ctx, unqualified)
+			if err != nil {
+				return nil, nil, err
+			}
+			for region, instanceIDs := range located {
+				byRegion[region] = append(byRegion[region], instanceIDs...)
+			}
+		}
+	}
+	if options.skipInvalid {
+		valid, err := multiRegion.Validate(ctx, byRegion)
+		if err := skipInvalid(err); err != nil {
+			return nil, nil, err
+		}
+		byRegion = valid
+	}
+	if options.preflight {
+		var failed error
+		for region, checks := range multiRegion.Preflight(ctx, byRegion) {
+			fmt.Printf("%s:\n%s", region, cli.ChecksSummary(checks))
+			failed = multierr.Append(failed, checks.Err())
+		}
+		if failed != nil {
+			return nil, nil, failed
+		}
+	}
+	if err := multiRegion.CheckGuardrails(ctx, byRegion, options.guardrails); err != nil {
+		return nil, nil, err
+	}
+	if !options.yes {
+		accountID, err := itn.New(cfg).AccountID(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := confirm(map[string]map[string][]string{accountID: byRegion}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return multiRegion.Interrupt(ctx, byRegion, options.interruptOptions())
+}
+
+// interruptAccounts interrupts the instances selected by tags in --regions, or the default region, of each account
+// passed in --accounts or belonging to --organizational-unit.
+func interruptAccounts(ctx context.Context, cfg aws.Config, options Options) ([]itn.Run, <-chan itn.Event, error) {
+	if len(options.tags) == 0 {
+		return nil, nil, fmt.Errorf("--tags is required to select instances in multiple accounts")
+	}
+	if options.roleARN != "" {
+		return nil, nil, fmt.Errorf("--role-arn cannot be used with multiple accounts, since a role belongs to a single account")
+	}
+	accountIDs := options.accounts
+	if options.organizationalUnit != "" {
+		ouAccountIDs, err := itn.AccountsInOrganizationalUnit(ctx, cfg, options.organizationalUnit)
+		if err != nil {
+			return nil, nil, err
+		}
+		accountIDs = lo.Uniq(append(accountIDs, ouAccountIDs...))
+	}
+	if len(accountIDs) == 0 {
+		return nil, nil, fmt.Errorf("no accounts found in %s", options.organizationalUnit)
+	}
+	regions := options.regions
+	if len(regions) == 0 {
+		regions = []string{cfg.Region}
+	}
+	multiAccount := itn.NewMultiAccount(cfg, accountIDs, options.assumeRole, regions)
+	targets, err := multiAccount.Select(ctx, options.tags)
+	if err != nil {
+		return nil, nil, err
+	}
+	if options.preflight {
+		var failed error
+		for accountID, byRegion := range multiAccount.Preflight(ctx, targets) {
+			for region, checks := range byRegion {
+				fmt.Printf("%s/%s:\n%s", accountID, region, cli.ChecksSummary(checks))
+				if err := checks.Err(); err != nil {
+					failed = multierr.Append(failed, fmt.Errorf("%s: %w", accountID, err))
+				}
+			}
+		}
+		if failed != nil {
+			return nil, nil, failed
+		}
+	}
+	if err := multiAccount.CheckGuardrails(ctx, targets, options.guardrails); err != nil {
+		return nil, nil, err
+	}
+	if !options.yes {
+		if err := confirm(targets); err != nil {
+			return nil, nil, err
+		}
+	}
+	return multiAccount.Interrupt(ctx, targets, options.interruptOptions())
+}
+
+// skipInvalid prints the instances that failed validation so that they can be skipped, returning any other error
+func skipInvalid(err error) error {
+	var validationErr *itn.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	cli.PrintError(err)
+	fmt.Printf("⚠️  Skipping %d invalid instances\n", len(validationErr.Invalid))
+	return nil
+}
+
+// confirm asks for confirmation on the terminal before interrupting targets, grouped by account and region
+func confirm(targets map[string]map[string][]string) error {
+	if !cli.Confirm(os.Stdin, os.Stdout, targets) {
+		return errors.New("interruption cancelled")
+	}
+	return nil
+}
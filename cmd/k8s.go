@@ -0,0 +1,57 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/k8s"
+	"github.com/samber/lo"
+)
+
+// k8sTargets targets the instances backing the Kubernetes nodes matching --k8s-node-selector and --k8s-nodepool.
+// Instance IDs outside of the default region are qualified with their region. It returns the watchers that verify
+// the nodes are drained if --verify-drain was passed.
+func k8sTargets(ctx context.Context, defaultRegion string, options *Options) ([]itn.Watcher, error) {
+	cluster, err := k8s.NewCluster(options.kubeconfig, options.kubeContext)
+	if err != nil {
+		return nil, err
+	}
+	nodes, skipped, err := cluster.Nodes(ctx, options.k8sNodeSelector, options.k8sNodePool)
+	if options.output == outputText {
+		for _, reason := range skipped {
+			fmt.Printf("⚠️  Skipping %s\n", reason)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if options.output == outputText {
+		for _, node := range nodes {
+			fmt.Printf("☸️  %s is %s in %s\n", node.Name, node.InstanceID, node.Region)
+		}
+	}
+	options.instanceIDs = lo.Map(nodes, func(node k8s.Node, _ int) string {
+		if node.Region == "" || node.Region == defaultRegion {
+			return node.InstanceID
+		}
+		return fmt.Sprintf("%s:%s", node.Region, node.InstanceID)
+	})
+	if !options.verifyDrain {
+		return nil, nil
+	}
+	return []itn.Watcher{cluster.DrainWatcher(ctx, nodes, itn.PollOptions{})}, nil
+}
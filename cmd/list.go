@@ -0,0 +1,64 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/cli"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+func listCommand(options *Options) *cobra.Command {
+	var state string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the Spot interruption experiments in the account and region, most recent first",
+		Run: func(cmd *cobra.Command, _ []string) {
+			states := types.ExperimentStatus("").Values()
+			if state != "" && !lo.Contains(states, types.ExperimentStatus(strings.ToLower(state))) {
+				fmt.Printf("❌ unknown state %q, the states are %v\n", state, states)
+				os.Exit(1)
+			}
+			ctx := context.Background()
+			cfg, err := loadConfig(ctx, *options)
+			if err != nil {
+				fmt.Printf("❌ %s\n", err)
+				os.Exit(1)
+			}
+			listings, err := itn.New(cfg).Experiments(ctx, types.ExperimentStatus(state))
+			if err != nil {
+				fmt.Printf("❌ %s\n", err)
+				os.Exit(1)
+			}
+			if options.output == outputJSON {
+				cli.PrintExperimentsJSON(listings)
+				return
+			}
+			if len(listings) == 0 {
+				fmt.Println("No interruption experiments found")
+				return
+			}
+			fmt.Print(cli.ExperimentsTable(listings))
+		},
+	}
+	listCmd.Flags().StringVar(&state, "state", "", fmt.Sprintf("only list the experiments in this state, one of %v", types.ExperimentStatus("").Values()))
+	return listCmd
+}
@@ -21,27 +21,82 @@ import (
 
 	"github.com/aws/amazon-ec2-spot-interrupter/pkg/cli"
 	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/notify"
 	"github.com/aws/amazon-ec2-spot-interrupter/pkg/tui"
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 )
 
 // TODOs(bwagner5):
-//   1. Option to pass tags instead of instance IDs
-//   2. Option to pass an OD instance and have this tool create a matching instance that is spot to test an interruption
-//   3. Automated chaos - give this tool a tag or vpc and allow it to randomly interrupt spot instances at will
+//   1. Option to pass an OD instance and have this tool create a matching instance that is spot to test an interruption
+//   2. Automated chaos - give this tool a tag or vpc and allow it to randomly interrupt spot instances at will
 
 var version string
 
 type Options struct {
-	instanceIDs []string
-	delay       time.Duration
-	clean       bool
-	version     bool
-	region      string
-	profile     string
-	interactive bool
+	instanceIDs         []string
+	tags                map[string]string
+	delay               time.Duration
+	clean               bool
+	version             bool
+	region              string
+	regions             []string
+	profile             string
+	interactive         bool
+	accounts            []string
+	organizationalUnit  string
+	assumeRole          string
+	preflight           bool
+	skipInvalid         bool
+	protectedTags       map[string]string
+	maxInstances        int
+	maxASGFraction      float64
+	yes                 bool
+	configPath          string
+	preset              string
+	mode                string
+	roleARN             string
+	stopAlarms          []string
+	output              string
+	templateName        string
+	k8sNodeSelector     string
+	k8sNodePool         string
+	kubeconfig          string
+	kubeContext         string
+	verifyDrain         bool
+	ecsCluster          string
+	ecsCapacityProvider string
+	watchRecovery       bool
+	probeURL            string
+	probeStatus         int
+	probeCmd            string
+	probeInterval       time.Duration
+	probeThreshold      float64
+	probeAfter          time.Duration
+	notifyWebhook       string
+	notifySlack         string
+	notifyTeams         string
+	notifyTemplate      string
+	notifyEvents        []string
+	eventBus            string
+	otlpEndpoint        string
+	guardrails          itn.Guardrails
+}
+
+// interruptOptions returns the options of the experiments to start
+func (o Options) interruptOptions() itn.Options {
+	return itn.Options{
+		Delay:        o.delay,
+		Clean:        o.clean,
+		Mode:         itn.Mode(o.mode),
+		RoleARN:      o.roleARN,
+		StopAlarms:   o.stopAlarms,
+		Tags:         o.tags,
+		TemplateName: o.templateName,
+	}
 }
 
 func main() {
@@ -49,40 +104,180 @@ func main() {
 	rootCmd := &cobra.Command{
 		Use:   "ec2-spot-interrupter",
 		Short: "ec2-spot-interrupter is a simple CLI tool that triggers Amazon EC2 Spot Instance Interruption Notifications and Rebalance Recommendations.",
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			return applySettings(cmd, &options)
+		},
 		Run: func(cmd *cobra.Command, _ []string) {
 			if options.version {
 				fmt.Println(version)
 				os.Exit(0)
 			}
 			ctx := context.Background()
-			cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(options.region), config.WithSharedConfigProfile(options.profile))
+			cfg, err := loadConfig(ctx, options)
 			if err != nil {
 				fmt.Printf("❌ %s\n", err)
 				os.Exit(1)
 			}
-			interrupter := itn.New(cfg)
+			if len(options.tags) > 0 && len(options.instanceIDs) > 0 {
+				fmt.Println("❌ --tags and --instance-ids cannot be used together")
+				os.Exit(1)
+			}
+			k8s := options.k8sNodeSelector != "" || options.k8sNodePool != ""
+			ecs := options.ecsCluster != ""
+			if (k8s || ecs) && (len(options.tags) > 0 || len(options.instanceIDs) > 0 || len(options.accounts) > 0 || options.organizationalUnit != "") {
+				fmt.Println("❌ Kubernetes and ECS selectors cannot be used with --tags, --instance-ids or multiple accounts")
+				os.Exit(1)
+			}
+			if k8s && ecs {
+				fmt.Println("❌ --ecs-cluster cannot be used with --k8s-node-selector or --k8s-nodepool")
+				os.Exit(1)
+			}
+			if options.ecsCapacityProvider != "" && !ecs {
+				fmt.Println("❌ --ecs-capacity-provider requires --ecs-cluster")
+				os.Exit(1)
+			}
+			flush, err := startTracing(ctx, options)
+			if err != nil {
+				fmt.Printf("❌ %s\n", err)
+				os.Exit(1)
+			}
+			// exit exports the spans left of the trace before exiting
+			exit := func(code int) {
+				flush()
+				os.Exit(code)
+			}
 			if options.interactive {
-				p := tea.NewProgram(tui.NewModel(ctx, interrupter))
+				if len(options.accounts) > 0 || options.organizationalUnit != "" || len(options.regions) > 0 {
+					fmt.Println("❌ --interactive does not support multiple regions or accounts")
+					exit(1)
+				}
+				p := tea.NewProgram(tui.NewModel(ctx, itn.New(cfg), options.interruptOptions(), options.guardrails, func(backend tui.Backend) ([]itn.Watcher, error) {
+					return watchers(ctx, backend, options)
+				}))
 				if err := p.Start(); err != nil {
 					fmt.Printf("❌ Error initializing TUI: %v", err)
-					os.Exit(1)
+					exit(1)
 				}
-				os.Exit(0)
+				exit(0)
+			}
+			if options.verifyDrain && !k8s && !ecs {
+				fmt.Println("❌ --verify-drain requires --k8s-node-selector, --k8s-nodepool or --ecs-cluster")
+				exit(1)
+			}
+			if options.watchRecovery && (len(options.accounts) > 0 || options.organizationalUnit != "" || len(options.regions) > 0) {
+				fmt.Println("❌ --watch-recovery does not support multiple regions or accounts")
+				exit(1)
+			}
+			var targetWatchers []itn.Watcher
+			switch {
+			case k8s:
+				targetWatchers, err = k8sTargets(ctx, cfg.Region, &options)
+			case ecs:
+				targetWatchers, err = ecsTargets(ctx, cfg, &options)
 			}
-			experiment, events, err := interrupter.Interrupt(context.Background(), options.instanceIDs, options.delay, options.clean)
 			if err != nil {
 				fmt.Printf("❌ %s\n", err)
-				os.Exit(1)
+				exit(1)
+			}
+			if options.watchRecovery {
+				targetWatchers = append(targetWatchers, itn.New(cfg).RecoveryWatcher(ctx, options.tags, itn.PollOptions{}))
+			}
+			for _, p := range probes(options) {
+				p.Start(ctx)
+				targetWatchers = append(targetWatchers, p.Watcher(ctx))
+			}
+			optionWatchers, err := watchers(ctx, itn.New(cfg), options)
+			if err != nil {
+				cli.PrintError(err)
+				exit(1)
+			}
+			runs, events, err := interrupt(ctx, cfg, options)
+			if err != nil {
+				cli.PrintError(err)
+				exit(1)
+			}
+			failed := false
+			events = itn.Watch(runs, events, append(append(targetWatchers, optionWatchers...), failures(&failed))...)
+			if options.output == outputJSON {
+				cli.PrintMonitorJSON(runs, events)
+			} else {
+				cli.PrintMonitor(runs, events)
+			}
+			if failed {
+				exit(1)
 			}
-			cli.PrintMonitor(experiment, events)
+			flush()
 		},
 	}
 	rootCmd.PersistentFlags().StringSliceVarP(&options.instanceIDs, "instance-ids", "i", []string{}, "instance IDs to interrupt")
+	rootCmd.PersistentFlags().StringToStringVarP(&options.tags, "tags", "t", map[string]string{}, "interrupt the running Spot instances with all of these tags instead of instance IDs")
 	rootCmd.PersistentFlags().BoolVarP(&options.clean, "clean", "c", true, "clean up the underlying simulations")
 	rootCmd.PersistentFlags().DurationVarP(&options.delay, "delay", "d", time.Second*15, "duration until the interruption notification is sent")
 	rootCmd.PersistentFlags().BoolVarP(&options.version, "version", "v", false, "the version")
 	rootCmd.PersistentFlags().BoolVar(&options.interactive, "interactive", false, "interactive TUI")
 	rootCmd.PersistentFlags().StringVarP(&options.region, "region", "r", "", "the AWS Region")
+	rootCmd.PersistentFlags().StringSliceVar(&options.regions, "regions", []string{}, "AWS Regions to interrupt instances in, unqualified instance IDs are looked up in each")
 	rootCmd.PersistentFlags().StringVarP(&options.profile, "profile", "p", "", "the AWS Profile")
+	rootCmd.PersistentFlags().StringSliceVar(&options.accounts, "accounts", []string{}, "AWS account IDs to interrupt the instances selected by --tags in")
+	rootCmd.PersistentFlags().StringVar(&options.organizationalUnit, "organizational-unit", "", "AWS Organizations OU whose accounts to interrupt the instances selected by --tags in")
+	rootCmd.PersistentFlags().StringVar(&options.assumeRole, "assume-role", "OrganizationAccountAccessRole", "name of the IAM role to assume in each of --accounts")
+	rootCmd.PersistentFlags().BoolVar(&options.preflight, "preflight", false, "run the pre-flight checks of the doctor command before starting the experiment")
+	rootCmd.PersistentFlags().BoolVar(&options.skipInvalid, "skip-invalid", false, "skip instances that fail validation and interrupt the rest")
+	rootCmd.PersistentFlags().StringToStringVar(&options.protectedTags, "protected-tags", map[string]string{"spot-interrupter/protected": "true"}, "refuse to interrupt instances with any of these tags")
+	rootCmd.PersistentFlags().IntVar(&options.maxInstances, "max-instances", 0, "refuse to interrupt more than this many instances, 0 for no limit")
+	rootCmd.PersistentFlags().Float64Var(&options.maxASGFraction, "max-asg-fraction", 0, "refuse to interrupt more than this fraction of the running instances of any Auto Scaling group, 0 for no limit")
+	rootCmd.PersistentFlags().BoolVarP(&options.yes, "yes", "y", false, "skip the confirmation prompt")
+	rootCmd.PersistentFlags().StringVar(&options.configPath, "config", "", "the configuration file (default $XDG_CONFIG_HOME/ec2-spot-interrupter/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&options.preset, "preset", "", "the preset of the configuration file to use")
+	rootCmd.PersistentFlags().StringVar(&options.mode, "mode", string(itn.ModeEphemeral), fmt.Sprintf("how the experiment template is managed, one of %v. Persistent mode reuses a named template for the instances selected by --tags", itn.Modes))
+	rootCmd.PersistentFlags().StringVar(&options.roleARN, "role-arn", "", "IAM role for FIS to run the experiment as instead of creating one")
+	rootCmd.PersistentFlags().StringSliceVar(&options.stopAlarms, "stop-alarms", []string{}, "CloudWatch alarm ARNs that stop the experiment when any of them is in alarm")
+	rootCmd.PersistentFlags().StringVar(&options.templateName, "template-name", "ec2-spot-interrupter", "name of the experiment template reused in persistent mode")
+	rootCmd.PersistentFlags().StringVar(&options.k8sNodeSelector, "k8s-node-selector", "", "interrupt the instances backing the Kubernetes nodes with this label selector, like karpenter.sh/capacity-type=spot")
+	rootCmd.PersistentFlags().StringVar(&options.k8sNodePool, "k8s-nodepool", "", "interrupt the instances backing the nodes of this Karpenter NodePool")
+	rootCmd.PersistentFlags().StringVar(&options.kubeconfig, "kubeconfig", "", "the kubeconfig to select Kubernetes nodes with (default $KUBECONFIG or ~/.kube/config)")
+	rootCmd.PersistentFlags().StringVar(&options.kubeContext, "kube-context", "", "the kubeconfig context to select Kubernetes nodes with (default the current context)")
+	rootCmd.PersistentFlags().BoolVar(&options.verifyDrain, "verify-drain", false, "after the interruption notification, verify the Kubernetes nodes are cordoned and drained before shutdown, or the ECS container instances are DRAINING and their tasks rescheduled")
+	rootCmd.PersistentFlags().BoolVar(&options.watchRecovery, "watch-recovery", false, "after the interruption notification, measure how long the Auto Scaling groups, EC2 Fleets or Karpenter NodePools of the instances, or the --tags scope, take to restore their running Spot capacity")
+	rootCmd.PersistentFlags().StringVar(&options.probeURL, "probe-url", "", "probe the health of an application with GETs of this URL throughout the interruption")
+	rootCmd.PersistentFlags().IntVar(&options.probeStatus, "probe-status", 200, "the status --probe-url is expected to respond with")
+	rootCmd.PersistentFlags().StringVar(&options.probeCmd, "probe-cmd", "", "probe the health of an application with this shell command throughout the interruption, healthy when it exits 0")
+	rootCmd.PersistentFlags().DurationVar(&options.probeInterval, "probe-interval", time.Second, "how often to probe, and how long each probe may take")
+	rootCmd.PersistentFlags().Float64Var(&options.probeThreshold, "probe-threshold", 0.95, "fail the run if the probes succeed less than this fraction of the time in any phase of the interruption")
+	rootCmd.PersistentFlags().DurationVar(&options.probeAfter, "probe-after", 30*time.Second, "how long to keep probing after the instances shut down")
+	rootCmd.PersistentFlags().StringVar(&options.notifyWebhook, "notify-webhook", "", "post the events of the interruption as JSON to this webhook URL")
+	rootCmd.PersistentFlags().StringVar(&options.notifySlack, "notify-slack", "", "post the events of the interruption to this Slack incoming webhook URL")
+	rootCmd.PersistentFlags().StringVar(&options.notifyTeams, "notify-teams", "", "post the events of the interruption to this Microsoft Teams incoming webhook URL")
+	rootCmd.PersistentFlags().StringVar(&options.notifyTemplate, "notify-template", notify.DefaultTemplate, "Go template of the notification messages, executed with the event and its .ExperimentIDs")
+	rootCmd.PersistentFlags().StringSliceVar(&options.notifyEvents, "notify-events", lo.Map(notify.DefaultEvents, func(t itn.EventType, _ int) string { return string(t) }), "the types of the events to notify")
+	rootCmd.PersistentFlags().StringVar(&options.eventBus, "event-bus", "", "publish each lifecycle transition of the interruption to this Amazon EventBridge event bus, like default")
+	rootCmd.PersistentFlags().StringVar(&options.otlpEndpoint, "otlp-endpoint", "", "export a trace of the interruption over OTLP/HTTP to the collector at this endpoint, like http://localhost:4318")
+	rootCmd.PersistentFlags().StringVar(&options.ecsCluster, "ecs-cluster", "", "interrupt the instances backing the container instances of this ECS cluster in the region")
+	rootCmd.PersistentFlags().StringVar(&options.ecsCapacityProvider, "ecs-capacity-provider", "", "only interrupt the container instances of this capacity provider of --ecs-cluster")
+	rootCmd.PersistentFlags().StringVarP(&options.output, "output", "o", outputText, fmt.Sprintf("output format, one of %v", outputFormats))
+	rootCmd.AddCommand(doctorCommand(&options))
+	rootCmd.AddCommand(historyCommand())
+	rootCmd.AddCommand(listCommand(&options))
+	rootCmd.AddCommand(statusCommand(&options))
+	rootCmd.AddCommand(watchCommand(&options))
 	rootCmd.Execute()
 }
+
+func loadConfig(ctx context.Context, options Options) (aws.Config, error) {
+	return awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(options.region), awsconfig.WithSharedConfigProfile(options.profile))
+}
+
+// failures passes the events through, noting whether any of them were errors so the run can fail
+func failures(failed *bool) itn.Watcher {
+	return func(_ []itn.Run, events <-chan itn.Event) <-chan itn.Event {
+		out := make(chan itn.Event)
+		go func() {
+			defer close(out)
+			for event := range events {
+				*failed = *failed || event.Type == itn.EventError
+				out <- event
+			}
+		}()
+		return out
+	}
+}
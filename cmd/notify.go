@@ -0,0 +1,73 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/k8s"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/notify"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/probe"
+	"github.com/samber/lo"
+)
+
+// notifyAttempts is how many times a notification is posted before giving up on it
+const notifyAttempts = 4
+
+// notifyEventTypes are the types of the events --notify-events can pick, which are those of the interruption and of
+// the watchers ahead of the notifier
+var notifyEventTypes = []itn.EventType{
+	itn.EventRebalanceRecommendation,
+	itn.EventInterruptionScheduled,
+	itn.EventExperimentPending,
+	itn.EventExperimentInitiating,
+	itn.EventInterruptionNotification,
+	itn.EventShutdown,
+	itn.EventError,
+	itn.EventPublishFailed,
+	itn.EventReplacementLaunched,
+	itn.EventCapacityRestored,
+	itn.EventRecoveryReport,
+	itn.EventContainerInstanceDraining,
+	itn.EventServiceRescheduled,
+	itn.EventTasksRescheduled,
+	itn.EventECSDrainReport,
+	k8s.EventNodeCordoned,
+	k8s.EventPodEvicted,
+	k8s.EventNodeDrained,
+	k8s.EventNodeDeleted,
+	k8s.EventDrainReport,
+	probe.EventProbeReport,
+}
+
+// notifier returns the notifier of --notify-webhook, --notify-slack and --notify-teams, or nil if none are set
+func notifier(options Options) (*notify.Notifier, error) {
+	var sinks []notify.Sink
+	if options.notifyWebhook != "" {
+		sinks = append(sinks, notify.Webhook(options.notifyWebhook))
+	}
+	if options.notifySlack != "" {
+		sinks = append(sinks, notify.Slack(options.notifySlack))
+	}
+	if options.notifyTeams != "" {
+		sinks = append(sinks, notify.Teams(options.notifyTeams))
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	return notify.New(sinks, notify.Options{
+		Template: options.notifyTemplate,
+		Events:   lo.Map(options.notifyEvents, func(t string, _ int) itn.EventType { return itn.EventType(t) }),
+		Attempts: notifyAttempts,
+	})
+}
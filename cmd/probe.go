@@ -0,0 +1,33 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/probe"
+)
+
+// probes returns the application health probes of --probe-url and --probe-cmd
+func probes(options Options) []*probe.Probe {
+	opts := probe.Options{Interval: options.probeInterval, Threshold: options.probeThreshold, After: options.probeAfter}
+	var probes []*probe.Probe
+	if options.probeURL != "" {
+		probes = append(probes, probe.New(fmt.Sprintf("GET %s", options.probeURL), probe.HTTP(options.probeURL, options.probeStatus), opts))
+	}
+	if options.probeCmd != "" {
+		probes = append(probes, probe.New(fmt.Sprintf("`%s`", options.probeCmd), probe.Command(options.probeCmd), opts))
+	}
+	return probes
+}
@@ -0,0 +1,228 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/config"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	envPrefix  = "EC2_SPOT_INTERRUPTER_"
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var outputFormats = []string{outputText, outputJSON}
+
+// applySettings resolves the options from, in order of precedence, environment variables, flags, the selected
+// preset of the configuration file and the top-level settings of the configuration file
+func applySettings(cmd *cobra.Command, options *Options) error {
+	flags := cmd.Flags()
+	if err := applyEnv(flags, options); err != nil {
+		return err
+	}
+	path := options.configPath
+	if path == "" {
+		defaultPath, err := config.DefaultPath()
+		if err != nil {
+			return err
+		}
+		path = defaultPath
+	} else if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	file, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	settings, err := file.Preset(options.preset)
+	if err != nil {
+		return err
+	}
+	applyFileSettings(flags, options, settings)
+	options.guardrails = settings.Safety.Guardrails()
+	if flags.Changed("protected-tags") || options.guardrails.ProtectedTags == nil {
+		options.guardrails.ProtectedTags = options.protectedTags
+	}
+	if flags.Changed("max-instances") {
+		options.guardrails.MaxInstances = options.maxInstances
+	}
+	if flags.Changed("max-asg-fraction") {
+		options.guardrails.MaxASGFraction = options.maxASGFraction
+	}
+	if !lo.Contains(itn.Modes, itn.Mode(options.mode)) {
+		return fmt.Errorf("unsupported mode %q, the modes are %v", options.mode, itn.Modes)
+	}
+	if !lo.Contains(outputFormats, options.output) {
+		return fmt.Errorf("unsupported output format %q, the formats are %v", options.output, outputFormats)
+	}
+	if options.probeThreshold < 0 || options.probeThreshold > 1 {
+		return fmt.Errorf("--probe-threshold must be between 0 and 1, got %v", options.probeThreshold)
+	}
+	if options.probeInterval <= 0 {
+		return fmt.Errorf("--probe-interval must be positive, got %s", options.probeInterval)
+	}
+	if unknown, _ := lo.Difference(options.notifyEvents, lo.Map(notifyEventTypes, func(t itn.EventType, _ int) string { return string(t) })); len(unknown) > 0 {
+		return fmt.Errorf("unsupported --notify-events %v, the event types are %v", unknown, notifyEventTypes)
+	}
+	if _, err := notifier(*options); err != nil {
+		return fmt.Errorf("--notify-template: %w", err)
+	}
+	return nil
+}
+
+// applyEnv sets each flag from its environment variable, like EC2_SPOT_INTERRUPTER_DELAY for --delay, overriding the
+// value passed on the command line
+func applyEnv(flags *pflag.FlagSet, options *Options) error {
+	// map flags merge what they're set to into what they were set to on the command line, so their maps are cleared
+	// for the environment variable to replace them
+	maps := map[string]map[string]string{"tags": options.tags, "protected-tags": options.protectedTags}
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		value, ok := os.LookupEnv(envPrefix + strings.ToUpper(strings.ReplaceAll(flag.Name, "-", "_")))
+		if !ok || err != nil {
+			return
+		}
+		if slice, isSlice := flag.Value.(pflag.SliceValue); isSlice {
+			err = slice.Replace(strings.Split(value, ","))
+			flag.Changed = true
+			return
+		}
+		if flag.Changed {
+			clear(maps[flag.Name])
+		}
+		if setErr := flags.Set(flag.Name, value); setErr != nil {
+			err = fmt.Errorf("%s%s: %w", envPrefix, strings.ToUpper(strings.ReplaceAll(flag.Name, "-", "_")), setErr)
+		}
+	})
+	return err
+}
+
+// applyFileSettings sets the options from the configuration file that weren't set by flags or environment variables.
+// The instances to target are only taken from the file if none of them were selected by flags.
+func applyFileSettings(flags *pflag.FlagSet, options *Options, settings config.Settings) {
+	unset := func(name string) bool { return !flags.Changed(name) }
+	if unset("instance-ids") && unset("tags") && unset("k8s-node-selector") && unset("k8s-nodepool") && unset("ecs-cluster") {
+		if settings.InstanceIDs != nil {
+			options.instanceIDs = settings.InstanceIDs
+		}
+		if settings.Tags != nil {
+			options.tags = settings.Tags
+		}
+		options.k8sNodeSelector = settings.K8sNodeSelector
+		options.k8sNodePool = settings.K8sNodePool
+		options.ecsCluster = settings.ECSCluster
+		if unset("ecs-capacity-provider") {
+			options.ecsCapacityProvider = settings.ECSCapacityProvider
+		}
+	}
+	if settings.Kubeconfig != "" && unset("kubeconfig") {
+		options.kubeconfig = settings.Kubeconfig
+	}
+	if settings.KubeContext != "" && unset("kube-context") {
+		options.kubeContext = settings.KubeContext
+	}
+	if settings.VerifyDrain != nil && unset("verify-drain") {
+		options.verifyDrain = *settings.VerifyDrain
+	}
+	if settings.WatchRecovery != nil && unset("watch-recovery") {
+		options.watchRecovery = *settings.WatchRecovery
+	}
+	if settings.Probe.URL != "" && unset("probe-url") {
+		options.probeURL = settings.Probe.URL
+	}
+	if settings.Probe.Status != 0 && unset("probe-status") {
+		options.probeStatus = settings.Probe.Status
+	}
+	if settings.Probe.Command != "" && unset("probe-cmd") {
+		options.probeCmd = settings.Probe.Command
+	}
+	if settings.Probe.Interval != nil && unset("probe-interval") {
+		options.probeInterval = *settings.Probe.Interval
+	}
+	if settings.Probe.Threshold != nil && unset("probe-threshold") {
+		options.probeThreshold = *settings.Probe.Threshold
+	}
+	if settings.Probe.After != nil && unset("probe-after") {
+		options.probeAfter = *settings.Probe.After
+	}
+	if settings.Notify.Webhook != "" && unset("notify-webhook") {
+		options.notifyWebhook = settings.Notify.Webhook
+	}
+	if settings.Notify.Slack != "" && unset("notify-slack") {
+		options.notifySlack = settings.Notify.Slack
+	}
+	if settings.Notify.Teams != "" && unset("notify-teams") {
+		options.notifyTeams = settings.Notify.Teams
+	}
+	if settings.Notify.Template != "" && unset("notify-template") {
+		options.notifyTemplate = settings.Notify.Template
+	}
+	if settings.Notify.Events != nil && unset("notify-events") {
+		options.notifyEvents = settings.Notify.Events
+	}
+	if settings.EventBus != "" && unset("event-bus") {
+		options.eventBus = settings.EventBus
+	}
+	if settings.OTLPEndpoint != "" && unset("otlp-endpoint") {
+		options.otlpEndpoint = settings.OTLPEndpoint
+	}
+	if settings.Region != "" && unset("region") {
+		options.region = settings.Region
+	}
+	if settings.Regions != nil && unset("regions") {
+		options.regions = settings.Regions
+	}
+	if settings.Profile != "" && unset("profile") {
+		options.profile = settings.Profile
+	}
+	if settings.Accounts != nil && unset("accounts") {
+		options.accounts = settings.Accounts
+	}
+	if settings.OrganizationalUnit != "" && unset("organizational-unit") {
+		options.organizationalUnit = settings.OrganizationalUnit
+	}
+	if settings.AssumeRole != "" && unset("assume-role") {
+		options.assumeRole = settings.AssumeRole
+	}
+	if settings.Delay != nil && unset("delay") {
+		options.delay = *settings.Delay
+	}
+	if settings.Clean != nil && unset("clean") {
+		options.clean = *settings.Clean
+	}
+	if settings.Mode != "" && unset("mode") {
+		options.mode = settings.Mode
+	}
+	if settings.RoleARN != "" && unset("role-arn") {
+		options.roleARN = settings.RoleARN
+	}
+	if settings.StopAlarms != nil && unset("stop-alarms") {
+		options.stopAlarms = settings.StopAlarms
+	}
+	if settings.TemplateName != "" && unset("template-name") {
+		options.templateName = settings.TemplateName
+	}
+	if settings.Output != "" && unset("output") {
+		options.output = settings.Output
+	}
+}
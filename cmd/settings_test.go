@@ -0,0 +1,44 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"github.com/spf13/pflag"
+)
+
+func TestApplyEnv(t *testing.T) {
+	options := Options{}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringToStringVar(&options.tags, "tags", map[string]string{}, "")
+	flags.StringToStringVar(&options.protectedTags, "protected-tags", map[string]string{"spot-interrupter/protected": "true"}, "")
+	flags.StringSliceVar(&options.regions, "regions", []string{}, "")
+	flags.DurationVar(&options.delay, "delay", 15*time.Second, "")
+	h.Ok(t, flags.Parse([]string{"--tags", "team=web,env=prod", "--regions", "us-east-1", "--delay", "1m"}))
+
+	// the environment variables replace the flags rather than merging with them
+	t.Setenv("EC2_SPOT_INTERRUPTER_TAGS", "team=checkout")
+	t.Setenv("EC2_SPOT_INTERRUPTER_PROTECTED_TAGS", "tier=db")
+	t.Setenv("EC2_SPOT_INTERRUPTER_REGIONS", "us-west-2,eu-west-1")
+	t.Setenv("EC2_SPOT_INTERRUPTER_DELAY", "2m")
+	h.Ok(t, applyEnv(flags, &options))
+	h.Equals(t, map[string]string{"team": "checkout"}, options.tags)
+	h.Equals(t, map[string]string{"tier": "db"}, options.protectedTags)
+	h.Equals(t, []string{"us-west-2", "eu-west-1"}, options.regions)
+	h.Equals(t, 2*time.Minute, options.delay)
+	h.Assert(t, flags.Changed("protected-tags"), "expected the environment variable to count as setting the flag")
+}
@@ -0,0 +1,87 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/cli"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+func statusCommand(options *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status <experiment-id>",
+		Short: "Show the state of an existing interruption experiment",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+			cfg, err := loadConfig(ctx, *options)
+			if err != nil {
+				fmt.Printf("❌ %s\n", err)
+				os.Exit(1)
+			}
+			experiment, err := itn.New(cfg).Experiment(ctx, args[0])
+			if err != nil {
+				fmt.Printf("❌ %s\n", err)
+				os.Exit(1)
+			}
+			if options.output == outputJSON {
+				cli.PrintExperimentStatusJSON(experiment)
+				return
+			}
+			fmt.Print(cli.ExperimentStatus(experiment))
+		},
+	}
+}
+
+func watchCommand(options *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch <experiment-id>",
+		Short: "Monitor an existing interruption experiment, like one started by someone else, without creating anything",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+			cfg, err := loadConfig(ctx, *options)
+			if err != nil {
+				fmt.Printf("❌ %s\n", err)
+				os.Exit(1)
+			}
+			interrupter := itn.New(cfg)
+			experiment, events, err := interrupter.Attach(ctx, args[0])
+			if err != nil {
+				fmt.Printf("❌ %s\n", err)
+				os.Exit(1)
+			}
+			runs := []itn.Run{{Region: interrupter.Region(), Experiment: experiment}}
+			if options.interactive {
+				if err := tea.NewProgram(tui.NewMonitor(ctx, interrupter, runs[0], events)).Start(); err != nil {
+					fmt.Printf("❌ Error initializing TUI: %v", err)
+					os.Exit(1)
+				}
+				return
+			}
+			if options.output == outputJSON {
+				cli.PrintMonitorJSON(runs, events)
+				return
+			}
+			cli.PrintMonitor(runs, events)
+		},
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/tracing"
+)
+
+// tracingTimeout is how long exporting the spans left when exiting may take
+const tracingTimeout = 10 * time.Second
+
+// startTracing exports a trace of the interruption to --otlp-endpoint, if it's set. The returned flush exports the
+// spans left and must be called before exiting.
+func startTracing(ctx context.Context, options Options) (flush func(), err error) {
+	if options.otlpEndpoint == "" {
+		return func() {}, nil
+	}
+	shutdown, err := tracing.Start(ctx, options.otlpEndpoint, version)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(ctx, tracingTimeout)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			fmt.Printf("⚠️ Exporting the trace to %s failed: %v\n", options.otlpEndpoint, err)
+		}
+	}, nil
+}
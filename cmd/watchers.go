@@ -0,0 +1,46 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/history"
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+)
+
+// publisher publishes the lifecycle of the runs to EventBridge, like an *itn.ITN or the backend of the TUI
+type publisher interface {
+	EventBridgeWatcher(ctx context.Context, eventBus string) itn.Watcher
+}
+
+// watchers are the watchers of every run, publishing to EventBridge with the publisher the run is started from. The
+// history is skipped if there's nowhere to keep it.
+func watchers(ctx context.Context, publisher publisher, options Options) ([]itn.Watcher, error) {
+	var watchers []itn.Watcher
+	if options.eventBus != "" {
+		watchers = append(watchers, publisher.EventBridgeWatcher(ctx, options.eventBus))
+	}
+	notifier, err := notifier(options)
+	if err != nil {
+		return nil, err
+	}
+	if notifier != nil {
+		watchers = append(watchers, notifier.Watcher())
+	}
+	if path, err := history.DefaultPath(); err == nil {
+		watchers = append(watchers, history.NewStore(path).Watcher(options.interruptOptions()))
+	}
+	return watchers, nil
+}
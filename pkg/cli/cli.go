@@ -14,33 +14,391 @@
 package cli
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/history"
 	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
 	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	"github.com/samber/lo"
 )
 
 func Summary(experiment *types.Experiment) string {
+	var instanceIDs []string
+	for _, target := range experiment.Targets {
+		for _, arn := range target.ResourceArns {
+			instanceIDs = append(instanceIDs, itn.ARNToInstanceID(arn))
+		}
+	}
+	return summary(*experiment.Id, *experiment.RoleArn, instanceIDs, "")
+}
+
+// PlanSummary summarizes an experiment before it's started, along with the account and region it will run in
+func PlanSummary(accountID string, region string, instanceIDs []string, opts itn.Options) string {
+	roleARN := lo.Ternary(opts.RoleARN == "", "(created or reused by the interrupter)", opts.RoleARN)
+	s := ""
+	s += fmt.Sprintf("   Account: %s\n", accountID)
+	s += fmt.Sprintf("    Region: %s\n", region)
+	s += fmt.Sprintf("      Mode: %s\n", lo.Ternary(opts.Mode == "", itn.ModeEphemeral, opts.Mode))
+	s += fmt.Sprintf("     Delay: %s\n", opts.Delay)
+	s += fmt.Sprintf("     Clean: %t\n", opts.Clean)
+	if len(opts.StopAlarms) > 0 {
+		s += "    Alarms:\n"
+		for _, alarm := range opts.StopAlarms {
+			s += fmt.Sprintf("    - %s\n", alarm)
+		}
+	}
+	return summary("(not started)", roleARN, instanceIDs, s)
+}
+
+func summary(id string, roleARN string, instanceIDs []string, details string) string {
 	// TODO: use a table lib to make this prettier
 	s := ""
 	s += "===================================================================\n"
 	s += "📖 Experiment Summary: \n"
-	s += fmt.Sprintf("        ID: %s\n", *experiment.Id)
-	s += fmt.Sprintf("  Role ARN: %s\n", *experiment.RoleArn)
+	s += fmt.Sprintf("        ID: %s\n", id)
+	s += fmt.Sprintf("  Role ARN: %s\n", roleARN)
 	s += fmt.Sprintf("    Action: %s\n", itn.SpotITNAction)
+	s += details
 	s += "   Targets:\n"
+	for _, instanceID := range instanceIDs {
+		s += fmt.Sprintf("    - %s\n", instanceID)
+	}
+	s += "===================================================================\n"
+	return s
+}
+
+// ExperimentStatus summarizes an existing experiment along with its state
+func ExperimentStatus(experiment *types.Experiment) string {
+	s := Summary(experiment)
+	if experiment.State != nil {
+		s += fmt.Sprintf("    Status: %s\n", experiment.State.Status)
+		if experiment.State.Reason != nil {
+			s += fmt.Sprintf("    Reason: %s\n", *experiment.State.Reason)
+		}
+	}
+	if experiment.StartTime != nil {
+		s += fmt.Sprintf("   Started: %s\n", experiment.StartTime.Format("2006-01-02T15:04:05"))
+	}
+	if experiment.EndTime != nil {
+		s += fmt.Sprintf("     Ended: %s\n", experiment.EndTime.Format("2006-01-02T15:04:05"))
+	}
+	if delay, err := itn.ExperimentDelay(experiment); err == nil {
+		s += fmt.Sprintf("     Delay: %s\n", delay)
+	}
+	return s
+}
+
+type statusJSON struct {
+	ExperimentID string     `json:"experimentId"`
+	TemplateID   string     `json:"templateId,omitempty"`
+	Status       string     `json:"status"`
+	Reason       string     `json:"reason,omitempty"`
+	StartTime    *time.Time `json:"startTime,omitempty"`
+	EndTime      *time.Time `json:"endTime,omitempty"`
+	Delay        string     `json:"delay,omitempty"`
+	InstanceIDs  []string   `json:"instanceIds"`
+}
+
+// PrintExperimentStatusJSON prints an existing experiment along with its state as JSON for scripting
+func PrintExperimentStatusJSON(experiment *types.Experiment) {
+	s := statusJSON{
+		ExperimentID: *experiment.Id,
+		TemplateID:   lo.FromPtr(experiment.ExperimentTemplateId),
+		StartTime:    experiment.StartTime,
+		EndTime:      experiment.EndTime,
+		InstanceIDs:  targetInstanceIDs(experiment),
+	}
+	if experiment.State != nil {
+		s.Status = string(experiment.State.Status)
+		s.Reason = lo.FromPtr(experiment.State.Reason)
+	}
+	if delay, err := itn.ExperimentDelay(experiment); err == nil {
+		s.Delay = delay.String()
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(s)
+}
+
+// CombinedSummary summarizes the experiments of every account and region in a run
+func CombinedSummary(runs []itn.Run) string {
+	if len(runs) == 1 && runs[0].AccountID == "" {
+		return Summary(runs[0].Experiment)
+	}
+	targets := 0
+	accounts := map[string]int{}
+	s := ""
+	s += "===================================================================\n"
+	s += "📖 Experiment Summary: \n"
+	for _, run := range runs {
+		if run.AccountID != "" {
+			s += fmt.Sprintf("   Account: %s\n", run.AccountID)
+		}
+		s += fmt.Sprintf("    Region: %s\n", run.Region)
+		s += fmt.Sprintf("        ID: %s\n", *run.Experiment.Id)
+		s += fmt.Sprintf("  Role ARN: %s\n", *run.Experiment.RoleArn)
+		s += "   Targets:\n"
+		for _, target := range run.Experiment.Targets {
+			for _, arn := range target.ResourceArns {
+				s += fmt.Sprintf("    - %s\n", itn.ARNToInstanceID(arn))
+				accounts[run.AccountID]++
+				targets++
+			}
+		}
+		s += "-------------------------------------------------------------------\n"
+	}
+	s += fmt.Sprintf("    Action: %s\n", itn.SpotITNAction)
+	regions := lo.Uniq(lo.Map(runs, func(run itn.Run, _ int) string { return run.Region }))
+	if _, ok := accounts[""]; ok {
+		s += fmt.Sprintf("     Total: %d instances in %d regions\n", targets, len(regions))
+	} else {
+		accountIDs := lo.Keys(accounts)
+		sort.Strings(accountIDs)
+		for _, accountID := range accountIDs {
+			s += fmt.Sprintf("   Account: %s: %d instances\n", accountID, accounts[accountID])
+		}
+		s += fmt.Sprintf("     Total: %d instances in %d regions across %d accounts\n", targets, len(regions), len(accounts))
+	}
+	s += "===================================================================\n"
+	return s
+}
+
+// FormatEvent renders an event as a single line, labeled with its account and region if it has them
+func FormatEvent(event itn.Event) string {
+	if scope := event.Scope(); scope != "" {
+		return fmt.Sprintf("%s: [%s] %s", event.Timestamp.Format("2006-01-02T15:04:05"), scope, event.Message)
+	}
+	return fmt.Sprintf("%s: %s", event.Timestamp.Format("2006-01-02T15:04:05"), event.Message)
+}
+
+func PrintMonitor(runs []itn.Run, events <-chan itn.Event) {
+	fmt.Print(CombinedSummary(runs))
+	for event := range events {
+		fmt.Println(FormatEvent(event))
+	}
+}
+
+type runJSON struct {
+	Region       string   `json:"region,omitempty"`
+	AccountID    string   `json:"accountId,omitempty"`
+	ExperimentID string   `json:"experimentId"`
+	InstanceIDs  []string `json:"instanceIds"`
+}
+
+type eventJSON struct {
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+	NextEvent string    `json:"nextEvent,omitempty"`
+	Region    string    `json:"region,omitempty"`
+	AccountID string    `json:"accountId,omitempty"`
+}
+
+// PrintMonitorJSON prints the runs and then each event as JSON lines for scripting
+func PrintMonitorJSON(runs []itn.Run, events <-chan itn.Event) {
+	encoder := json.NewEncoder(os.Stdout)
+	summary := struct {
+		Runs []runJSON `json:"runs"`
+	}{}
+	for _, run := range runs {
+		summary.Runs = append(summary.Runs, runJSON{
+			Region:       run.Region,
+			AccountID:    run.AccountID,
+			ExperimentID: *run.Experiment.Id,
+			InstanceIDs:  targetInstanceIDs(run.Experiment),
+		})
+	}
+	encoder.Encode(summary)
+	for event := range events {
+		e := eventJSON{
+			Timestamp: event.Timestamp,
+			Message:   event.Message,
+			Region:    event.Region,
+			AccountID: event.AccountID,
+		}
+		if event.NextEvent > 0 {
+			e.NextEvent = event.NextEvent.String()
+		}
+		encoder.Encode(e)
+	}
+}
+
+// targetInstanceIDs returns the sorted instance IDs targeted by an experiment
+func targetInstanceIDs(experiment *types.Experiment) []string {
+	var instanceIDs []string
 	for _, target := range experiment.Targets {
 		for _, arn := range target.ResourceArns {
-			s += fmt.Sprintf("    - %s\n", itn.ARNToInstanceID(arn))
+			instanceIDs = append(instanceIDs, itn.ARNToInstanceID(arn))
 		}
 	}
+	sort.Strings(instanceIDs)
+	return instanceIDs
+}
+
+// ChecksSummary renders the outcome of each pre-flight check on its own line
+func ChecksSummary(checks itn.Checks) string {
+	s := ""
+	for _, check := range checks {
+		s += fmt.Sprintf("%s %s: %s\n", check.Status, check.Name, check.Message)
+	}
+	return s
+}
+
+// ValidationTable renders the instances that failed validation as a table
+func ValidationTable(err *itn.ValidationError) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INSTANCE ID\tREGION\tREASON")
+	for _, invalid := range err.Invalid {
+		reason := string(invalid.Reason)
+		if invalid.Detail != "" {
+			reason = fmt.Sprintf("%s (%s)", reason, invalid.Detail)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", invalid.InstanceID, invalid.Region, reason)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// PrintError prints an error, rendering validation errors as a table
+func PrintError(err error) {
+	var validationErr *itn.ValidationError
+	if errors.As(err, &validationErr) {
+		fmt.Printf("❌ %d instances failed validation:\n%s", len(validationErr.Invalid), ValidationTable(validationErr))
+		return
+	}
+	fmt.Printf("❌ %s\n", err)
+}
+
+// TargetsSummary lists the instances about to be interrupted, grouped by account and region
+func TargetsSummary(targets map[string]map[string][]string) string {
+	s := ""
+	accountIDs := lo.Keys(targets)
+	sort.Strings(accountIDs)
+	for _, accountID := range accountIDs {
+		regions := lo.Keys(targets[accountID])
+		sort.Strings(regions)
+		for _, region := range regions {
+			s += fmt.Sprintf("%s/%s: %s\n", accountID, region, strings.Join(targets[accountID][region], ", "))
+		}
+	}
+	return s
+}
+
+// Confirm asks whether to interrupt targets, reading the answer from in. Anything other than y or yes is a no,
+// including reaching the end of in.
+func Confirm(in io.Reader, out io.Writer, targets map[string]map[string][]string) bool {
+	fmt.Fprintf(out, "About to interrupt:\n%sContinue? [y/N]: ", TargetsSummary(targets))
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// HistoryTable renders a line per experiment in the history, most recent first
+func HistoryTable(records []history.Record) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EXPERIMENT ID\tSTARTED\tACCOUNT\tREGION\tINSTANCES\tOUTCOME")
+	for j := len(records) - 1; j >= 0; j-- {
+		record := records[j]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", record.ExperimentID, record.StartTime.Format("2006-01-02T15:04:05"),
+			lo.Ternary(record.AccountID == "", "-", record.AccountID), record.Region, len(record.InstanceIDs), record.Outcome)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// HistoryDetail renders an experiment in the history along with its event log
+func HistoryDetail(record history.Record) string {
+	s := ""
+	s += "===================================================================\n"
+	s += "📖 Experiment History: \n"
+	s += fmt.Sprintf("        ID: %s\n", record.ExperimentID)
+	if record.AccountID != "" {
+		s += fmt.Sprintf("   Account: %s\n", record.AccountID)
+	}
+	s += fmt.Sprintf("    Region: %s\n", record.Region)
+	s += fmt.Sprintf("   Started: %s\n", record.StartTime.Format("2006-01-02T15:04:05"))
+	if record.EndTime != nil {
+		s += fmt.Sprintf("     Ended: %s\n", record.EndTime.Format("2006-01-02T15:04:05"))
+	}
+	s += fmt.Sprintf("   Outcome: %s\n", record.Outcome)
+	s += fmt.Sprintf("     Delay: %s\n", record.Settings.Delay)
+	s += fmt.Sprintf("     Clean: %t\n", record.Settings.Clean)
+	if record.Settings.RoleARN != "" {
+		s += fmt.Sprintf("  Role ARN: %s\n", record.Settings.RoleARN)
+	}
+	for _, alarm := range record.Settings.StopAlarms {
+		s += fmt.Sprintf("Stop Alarm: %s\n", alarm)
+	}
+	s += "   Targets:\n"
+	for _, instanceID := range record.InstanceIDs {
+		s += fmt.Sprintf("    - %s\n", instanceID)
+	}
 	s += "===================================================================\n"
+	for _, event := range record.Events {
+		s += fmt.Sprintf("%s: %s\n", event.Timestamp.Format("2006-01-02T15:04:05"), event.Message)
+	}
 	return s
 }
 
-func PrintMonitor(experiment *types.Experiment, events <-chan itn.Event) {
-	fmt.Print(Summary(experiment))
-	for event := range events {
-		fmt.Printf("%s: %s\n", event.Timestamp.Format("2006-01-02T15:04:05"), event.Message)
+// ExperimentsTable renders a line per interruption experiment
+func ExperimentsTable(listings []itn.Listing) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EXPERIMENT ID\tSTATUS\tSTARTED\tTARGETS\tSTARTED BY")
+	for _, listing := range listings {
+		started := "-"
+		if listing.Experiment.StartTime != nil {
+			started = listing.Experiment.StartTime.Format("2006-01-02T15:04:05")
+		}
+		status := ""
+		if listing.Experiment.State != nil {
+			status = string(listing.Experiment.State.Status)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", *listing.Experiment.Id, status, started,
+			strings.Join(listing.InstanceIDs(), ","), lo.Ternary(listing.StartedBy == "", "-", listing.StartedBy))
+	}
+	w.Flush()
+	return b.String()
+}
+
+type listingJSON struct {
+	ExperimentID string     `json:"experimentId"`
+	TemplateID   string     `json:"templateId,omitempty"`
+	Status       string     `json:"status"`
+	StartTime    *time.Time `json:"startTime,omitempty"`
+	EndTime      *time.Time `json:"endTime,omitempty"`
+	InstanceIDs  []string   `json:"instanceIds"`
+	StartedBy    string     `json:"startedBy,omitempty"`
+}
+
+// PrintExperimentsJSON prints the interruption experiments as a JSON array for scripting
+func PrintExperimentsJSON(listings []itn.Listing) {
+	out := []listingJSON{}
+	for _, listing := range listings {
+		l := listingJSON{
+			ExperimentID: *listing.Experiment.Id,
+			TemplateID:   lo.FromPtr(listing.Experiment.ExperimentTemplateId),
+			StartTime:    listing.Experiment.StartTime,
+			EndTime:      listing.Experiment.EndTime,
+			InstanceIDs:  listing.InstanceIDs(),
+			StartedBy:    listing.StartedBy,
+		}
+		if listing.Experiment.State != nil {
+			l.Status = string(listing.Experiment.State.Status)
+		}
+		out = append(out, l)
 	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(out)
 }
@@ -0,0 +1,73 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+)
+
+func TestValidationTable(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		invalid []itn.InvalidInstance
+		table   string
+	}{
+		{
+			name:  "no invalid instances",
+			table: "INSTANCE ID  REGION  REASON\n",
+		},
+		{
+			name: "reasons with and without details",
+			invalid: []itn.InvalidInstance{
+				{InstanceID: "i-typo", Region: "us-west-2", Reason: itn.ReasonNotFound},
+				{InstanceID: "i-0208a716009d70b36", Region: "us-west-2", Reason: itn.ReasonNotRunning, Detail: "stopped"},
+				{InstanceID: "i-west", Region: "us-east-1", Reason: itn.ReasonWrongRegion, Detail: "us-west-2"},
+			},
+			table: "INSTANCE ID          REGION     REASON\n" +
+				"i-typo               us-west-2  not found\n" +
+				"i-0208a716009d70b36  us-west-2  not running (stopped)\n" +
+				"i-west               us-east-1  in another region (us-west-2)\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			h.Equals(t, test.table, ValidationTable(&itn.ValidationError{Invalid: test.invalid}))
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	targets := map[string]map[string][]string{"111111111111": {"us-west-2": {"i-1", "i-2"}}}
+	for _, test := range []struct {
+		name      string
+		input     string
+		confirmed bool
+	}{
+		{name: "y", input: "y\n", confirmed: true},
+		{name: "yes", input: " Yes \n", confirmed: true},
+		{name: "n", input: "n\n", confirmed: false},
+		{name: "empty", input: "\n", confirmed: false},
+		{name: "EOF", input: "", confirmed: false},
+		{name: "y at EOF", input: "y", confirmed: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			h.Equals(t, test.confirmed, Confirm(strings.NewReader(test.input), &out, targets))
+			h.Equals(t, "About to interrupt:\n111111111111/us-west-2: i-1, i-2\nContinue? [y/N]: ", out.String())
+		})
+	}
+}
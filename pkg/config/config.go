@@ -0,0 +1,297 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the ec2-spot-interrupter configuration file. The top-level settings apply to every run, and
+// presets are named settings applied on top of them.
+type Config struct {
+	Settings `yaml:",inline"`
+	Presets  map[string]Settings `yaml:"presets"`
+}
+
+// Settings mirror the command line flags. Unset fields leave the flag defaults in place.
+type Settings struct {
+	InstanceIDs         []string          `yaml:"instanceIds"`
+	Tags                map[string]string `yaml:"tags"`
+	K8sNodeSelector     string            `yaml:"k8sNodeSelector"`
+	K8sNodePool         string            `yaml:"k8sNodePool"`
+	Kubeconfig          string            `yaml:"kubeconfig"`
+	KubeContext         string            `yaml:"kubeContext"`
+	VerifyDrain         *bool             `yaml:"verifyDrain"`
+	WatchRecovery       *bool             `yaml:"watchRecovery"`
+	ECSCluster          string            `yaml:"ecsCluster"`
+	ECSCapacityProvider string            `yaml:"ecsCapacityProvider"`
+	Region              string            `yaml:"region"`
+	Regions             []string          `yaml:"regions"`
+	Profile             string            `yaml:"profile"`
+	Accounts            []string          `yaml:"accounts"`
+	OrganizationalUnit  string            `yaml:"organizationalUnit"`
+	AssumeRole          string            `yaml:"assumeRole"`
+	Delay               *time.Duration    `yaml:"delay"`
+	Clean               *bool             `yaml:"clean"`
+	Mode                string            `yaml:"mode"`
+	RoleARN             string            `yaml:"roleArn"`
+	StopAlarms          []string          `yaml:"stopAlarms"`
+	TemplateName        string            `yaml:"templateName"`
+	Output              string            `yaml:"output"`
+	EventBus            string            `yaml:"eventBus"`
+	OTLPEndpoint        string            `yaml:"otlpEndpoint"`
+	Safety              Safety            `yaml:"safety"`
+	Probe               Probe             `yaml:"probe"`
+	Notify              Notify            `yaml:"notify"`
+}
+
+// Safety configures the guardrails in front of every interruption
+type Safety struct {
+	ProtectedTags   map[string]string `yaml:"protectedTags"`
+	MaxInstances    int               `yaml:"maxInstances"`
+	MaxASGFraction  float64           `yaml:"maxASGFraction"`
+	AllowedAccounts []string          `yaml:"allowedAccounts"`
+}
+
+// Probe configures the application health probes run throughout the interruption
+type Probe struct {
+	URL       string         `yaml:"url"`
+	Status    int            `yaml:"status"`
+	Command   string         `yaml:"command"`
+	Interval  *time.Duration `yaml:"interval"`
+	Threshold *float64       `yaml:"threshold"`
+	After     *time.Duration `yaml:"after"`
+}
+
+// Notify configures where the events of the interruption are posted
+type Notify struct {
+	Webhook  string   `yaml:"webhook"`
+	Slack    string   `yaml:"slack"`
+	Teams    string   `yaml:"teams"`
+	Template string   `yaml:"template"`
+	Events   []string `yaml:"events"`
+}
+
+// Preset returns the settings of the named preset applied on top of the top-level settings, or just the
+// top-level settings if name is empty
+func (c Config) Preset(name string) (Settings, error) {
+	if name == "" {
+		return c.Settings, nil
+	}
+	preset, ok := c.Presets[name]
+	if !ok {
+		return Settings{}, fmt.Errorf("preset %q not found, the presets are %v", name, c.PresetNames())
+	}
+	return c.Settings.merge(preset), nil
+}
+
+// PresetNames returns the sorted names of the presets
+func (c Config) PresetNames() []string {
+	names := lo.Keys(c.Presets)
+	sort.Strings(names)
+	return names
+}
+
+// merge returns s with every field set in override replaced
+func (s Settings) merge(override Settings) Settings {
+	merged := s
+	if override.InstanceIDs != nil {
+		merged.InstanceIDs = override.InstanceIDs
+	}
+	if override.Tags != nil {
+		merged.Tags = override.Tags
+	}
+	if override.K8sNodeSelector != "" {
+		merged.K8sNodeSelector = override.K8sNodeSelector
+	}
+	if override.K8sNodePool != "" {
+		merged.K8sNodePool = override.K8sNodePool
+	}
+	if override.Kubeconfig != "" {
+		merged.Kubeconfig = override.Kubeconfig
+	}
+	if override.KubeContext != "" {
+		merged.KubeContext = override.KubeContext
+	}
+	if override.ECSCluster != "" {
+		merged.ECSCluster = override.ECSCluster
+	}
+	if override.ECSCapacityProvider != "" {
+		merged.ECSCapacityProvider = override.ECSCapacityProvider
+	}
+	if override.VerifyDrain != nil {
+		merged.VerifyDrain = override.VerifyDrain
+	}
+	if override.WatchRecovery != nil {
+		merged.WatchRecovery = override.WatchRecovery
+	}
+	if override.Region != "" {
+		merged.Region = override.Region
+	}
+	if override.Regions != nil {
+		merged.Regions = override.Regions
+	}
+	if override.Profile != "" {
+		merged.Profile = override.Profile
+	}
+	if override.Accounts != nil {
+		merged.Accounts = override.Accounts
+	}
+	if override.OrganizationalUnit != "" {
+		merged.OrganizationalUnit = override.OrganizationalUnit
+	}
+	if override.AssumeRole != "" {
+		merged.AssumeRole = override.AssumeRole
+	}
+	if override.Delay != nil {
+		merged.Delay = override.Delay
+	}
+	if override.Clean != nil {
+		merged.Clean = override.Clean
+	}
+	if override.Mode != "" {
+		merged.Mode = override.Mode
+	}
+	if override.RoleARN != "" {
+		merged.RoleARN = override.RoleARN
+	}
+	if override.StopAlarms != nil {
+		merged.StopAlarms = override.StopAlarms
+	}
+	if override.TemplateName != "" {
+		merged.TemplateName = override.TemplateName
+	}
+	if override.Output != "" {
+		merged.Output = override.Output
+	}
+	if override.EventBus != "" {
+		merged.EventBus = override.EventBus
+	}
+	if override.OTLPEndpoint != "" {
+		merged.OTLPEndpoint = override.OTLPEndpoint
+	}
+	merged.Safety = s.Safety.merge(override.Safety)
+	merged.Probe = s.Probe.merge(override.Probe)
+	merged.Notify = s.Notify.merge(override.Notify)
+	return merged
+}
+
+func (s Safety) merge(override Safety) Safety {
+	merged := s
+	if override.ProtectedTags != nil {
+		merged.ProtectedTags = override.ProtectedTags
+	}
+	if override.MaxInstances != 0 {
+		merged.MaxInstances = override.MaxInstances
+	}
+	if override.MaxASGFraction != 0 {
+		merged.MaxASGFraction = override.MaxASGFraction
+	}
+	if override.AllowedAccounts != nil {
+		merged.AllowedAccounts = override.AllowedAccounts
+	}
+	return merged
+}
+
+func (p Probe) merge(override Probe) Probe {
+	merged := p
+	if override.URL != "" {
+		merged.URL = override.URL
+	}
+	if override.Status != 0 {
+		merged.Status = override.Status
+	}
+	if override.Command != "" {
+		merged.Command = override.Command
+	}
+	if override.Interval != nil {
+		merged.Interval = override.Interval
+	}
+	if override.Threshold != nil {
+		merged.Threshold = override.Threshold
+	}
+	if override.After != nil {
+		merged.After = override.After
+	}
+	return merged
+}
+
+func (n Notify) merge(override Notify) Notify {
+	merged := n
+	if override.Webhook != "" {
+		merged.Webhook = override.Webhook
+	}
+	if override.Slack != "" {
+		merged.Slack = override.Slack
+	}
+	if override.Teams != "" {
+		merged.Teams = override.Teams
+	}
+	if override.Template != "" {
+		merged.Template = override.Template
+	}
+	if override.Events != nil {
+		merged.Events = override.Events
+	}
+	return merged
+}
+
+// Guardrails converts the safety settings to the guardrails checked before interrupting
+func (s Safety) Guardrails() itn.Guardrails {
+	return itn.Guardrails{
+		ProtectedTags:   s.ProtectedTags,
+		MaxInstances:    s.MaxInstances,
+		MaxASGFraction:  s.MaxASGFraction,
+		AllowedAccounts: s.AllowedAccounts,
+	}
+}
+
+// DefaultPath returns the path of the configuration file under $XDG_CONFIG_HOME, or ~/.config if it isn't set
+func DefaultPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "ec2-spot-interrupter", "config.yaml"), nil
+}
+
+// Load reads the configuration file at path. A missing file is an empty configuration.
+func Load(path string) (*Config, error) {
+	config := &Config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return config, nil
+}
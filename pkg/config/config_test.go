@@ -0,0 +1,80 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+)
+
+func TestLoad(t *testing.T) {
+	// a missing file is an empty configuration
+	config, err := Load(filepath.Join(t.TempDir(), "config.yaml"))
+	h.Ok(t, err)
+	h.Equals(t, &Config{}, config)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	h.Ok(t, os.WriteFile(path, []byte(`
+region: us-east-1
+delay: 30s
+safety:
+  maxInstances: 10
+notify:
+  slack: https://hooks.slack.com/services/T000/B000/XXXX
+probe:
+  threshold: 0.99
+presets:
+  staging:
+    profile: staging
+    notify:
+      template: "staging: {{.Message}}"
+    delay: 1m
+    clean: false
+    tags:
+      team: checkout
+    safety:
+      maxASGFraction: 0.5
+    probe:
+      threshold: 0
+`), 0o600))
+	config, err = Load(path)
+	h.Ok(t, err)
+
+	settings, err := config.Preset("")
+	h.Ok(t, err)
+	h.Equals(t, "us-east-1", settings.Region)
+	h.Equals(t, 30*time.Second, *settings.Delay)
+	h.Assert(t, settings.Clean == nil, "expected clean to be unset")
+	h.Equals(t, 0.99, *settings.Probe.Threshold)
+
+	settings, err = config.Preset("staging")
+	h.Ok(t, err)
+	h.Equals(t, "us-east-1", settings.Region)
+	h.Equals(t, "staging", settings.Profile)
+	h.Equals(t, time.Minute, *settings.Delay)
+	h.Equals(t, false, *settings.Clean)
+	h.Equals(t, map[string]string{"team": "checkout"}, settings.Tags)
+	h.Equals(t, 10, settings.Safety.MaxInstances)
+	h.Equals(t, 0.5, settings.Safety.MaxASGFraction)
+	// a zero threshold overrides the top-level one
+	h.Equals(t, 0.0, *settings.Probe.Threshold)
+	h.Equals(t, Notify{Slack: "https://hooks.slack.com/services/T000/B000/XXXX", Template: "staging: {{.Message}}"}, settings.Notify)
+
+	_, err = config.Preset("production")
+	h.Nok(t, err)
+}
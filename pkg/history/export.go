@@ -0,0 +1,78 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Formats are the supported export formats
+var Formats = []string{FormatCSV, FormatJSON}
+
+// Export writes records to w in one of the Formats
+func Export(w io.Writer, records []Record, format string) error {
+	switch format {
+	case FormatCSV:
+		return exportCSV(w, records)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if records == nil {
+			records = []Record{}
+		}
+		return encoder.Encode(records)
+	default:
+		return fmt.Errorf("unsupported format %q, the formats are %v", format, Formats)
+	}
+}
+
+// exportCSV writes a row per record, leaving out the event log
+func exportCSV(w io.Writer, records []Record) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"experiment_id", "template_id", "account_id", "region", "instance_ids", "delay", "clean", "mode", "role_arn", "stop_alarms", "start_time", "end_time", "outcome"})
+	for _, record := range records {
+		endTime := ""
+		if record.EndTime != nil {
+			endTime = record.EndTime.Format(time.RFC3339)
+		}
+		writer.Write([]string{
+			record.ExperimentID,
+			record.TemplateID,
+			record.AccountID,
+			record.Region,
+			strings.Join(record.InstanceIDs, " "),
+			record.Settings.Delay,
+			strconv.FormatBool(record.Settings.Clean),
+			record.Settings.Mode,
+			record.Settings.RoleARN,
+			strings.Join(record.Settings.StopAlarms, " "),
+			record.StartTime.Format(time.RFC3339),
+			endTime,
+			string(record.Outcome),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
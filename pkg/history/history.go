@@ -0,0 +1,258 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/samber/lo"
+)
+
+// EventRecordFailed is a run failing to be recorded in the history, which doesn't fail the run
+const EventRecordFailed itn.EventType = "RecordFailed"
+
+// Outcome is how an experiment ended
+type Outcome string
+
+const (
+	OutcomeRunning   Outcome = "running"
+	OutcomeCompleted Outcome = "completed"
+	OutcomeFailed    Outcome = "failed"
+	// OutcomeUnknown is an experiment that stopped being monitored before it completed, like when the CLI exited
+	OutcomeUnknown Outcome = "unknown"
+)
+
+// Record is a single experiment in the history
+type Record struct {
+	ExperimentID string     `json:"experimentId"`
+	TemplateID   string     `json:"templateId,omitempty"`
+	Region       string     `json:"region"`
+	AccountID    string     `json:"accountId,omitempty"`
+	InstanceIDs  []string   `json:"instanceIds"`
+	Settings     Settings   `json:"settings"`
+	StartTime    time.Time  `json:"startTime"`
+	EndTime      *time.Time `json:"endTime,omitempty"`
+	Outcome      Outcome    `json:"outcome"`
+	Events       []Event    `json:"events"`
+}
+
+// Settings are the options an experiment was started with
+type Settings struct {
+	Delay      string   `json:"delay"`
+	Clean      bool     `json:"clean"`
+	Mode       string   `json:"mode,omitempty"`
+	RoleARN    string   `json:"roleArn,omitempty"`
+	StopAlarms []string `json:"stopAlarms,omitempty"`
+}
+
+// Event is an entry of the event log of an experiment
+type Event struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Type      itn.EventType `json:"type,omitempty"`
+	Message   string        `json:"message"`
+}
+
+// Store is the history of experiments, kept as JSON lines in a file. A record is appended when an experiment starts
+// and again when it ends, and the latest record of an experiment wins.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultPath returns the path of the history under $XDG_STATE_HOME, or ~/.local/state if it isn't set
+func DefaultPath() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "ec2-spot-interrupter", "history.jsonl"), nil
+}
+
+// Append adds a record to the history
+func (s *Store) Append(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// List returns the latest record of every experiment, oldest first. A missing history is empty.
+func (s *Store) List() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	latest := map[string]Record{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", s.path, line, err)
+		}
+		latest[record.ExperimentID] = record
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	records := lo.Values(latest)
+	sort.Slice(records, func(a, b int) bool { return records[a].StartTime.Before(records[b].StartTime) })
+	return records, nil
+}
+
+// Get returns the latest record of an experiment
+func (s *Store) Get(experimentID string) (Record, error) {
+	records, err := s.List()
+	if err != nil {
+		return Record{}, err
+	}
+	record, ok := lo.Find(records, func(record Record) bool { return record.ExperimentID == experimentID })
+	if !ok {
+		return Record{}, fmt.Errorf("experiment %s not found in the history", experimentID)
+	}
+	return record, nil
+}
+
+// Watcher records runs in the store as they start and end, along with their event logs. Failing to record an
+// experiment is reported in the event stream rather than interrupting it.
+func (s *Store) Watcher(opts itn.Options) itn.Watcher {
+	return func(runs []itn.Run, events <-chan itn.Event) <-chan itn.Event {
+		out := make(chan itn.Event, 10)
+		records := lo.Map(runs, func(run itn.Run, _ int) *Record { return newRecord(run, opts) })
+		go func() {
+			defer close(out)
+			s.appendAll(records, out)
+			for event := range events {
+				for j, run := range runs {
+					if belongsTo(event, run) {
+						records[j].add(event)
+					}
+				}
+				out <- event
+			}
+			now := time.Now()
+			for _, record := range records {
+				record.EndTime = &now
+				record.Outcome = record.outcome()
+			}
+			s.appendAll(records, out)
+		}()
+		return out
+	}
+}
+
+func (s *Store) appendAll(records []*Record, events chan<- itn.Event) {
+	for _, record := range records {
+		if err := s.Append(*record); err != nil {
+			events <- itn.Event{
+				Timestamp: time.Now(),
+				Type:      EventRecordFailed,
+				Message:   fmt.Sprintf("⚠️ Recording experiment %s in the history failed: %v", record.ExperimentID, err),
+				Region:    record.Region,
+				AccountID: record.AccountID,
+			}
+		}
+	}
+}
+
+func newRecord(run itn.Run, opts itn.Options) *Record {
+	record := &Record{
+		ExperimentID: lo.FromPtr(run.Experiment.Id),
+		TemplateID:   lo.FromPtr(run.Experiment.ExperimentTemplateId),
+		Region:       run.Region,
+		AccountID:    run.AccountID,
+		Settings: Settings{
+			Delay:      opts.Delay.String(),
+			Clean:      opts.Clean,
+			Mode:       string(opts.Mode),
+			RoleARN:    opts.RoleARN,
+			StopAlarms: opts.StopAlarms,
+		},
+		StartTime: lo.FromPtrOr(run.Experiment.StartTime, time.Now()),
+		Outcome:   OutcomeRunning,
+		Events:    []Event{},
+	}
+	// the experiment knows best what it was started with, like when the delay was changed in the TUI
+	if delay, err := itn.ExperimentDelay(run.Experiment); err == nil {
+		record.Settings.Delay = delay.String()
+	}
+	if run.Experiment.RoleArn != nil {
+		record.Settings.RoleARN = *run.Experiment.RoleArn
+	}
+	for _, target := range run.Experiment.Targets {
+		for _, arn := range target.ResourceArns {
+			record.InstanceIDs = append(record.InstanceIDs, itn.ARNToInstanceID(arn))
+		}
+	}
+	sort.Strings(record.InstanceIDs)
+	return record
+}
+
+func (r *Record) add(event itn.Event) {
+	r.Events = append(r.Events, Event{Timestamp: event.Timestamp, Type: event.Type, Message: event.Message})
+}
+
+func (r Record) outcome() Outcome {
+	if lo.ContainsBy(r.Events, func(event Event) bool { return event.Type == itn.EventError }) {
+		return OutcomeFailed
+	}
+	if lo.ContainsBy(r.Events, func(event Event) bool { return event.Type == itn.EventShutdown }) {
+		return OutcomeCompleted
+	}
+	return OutcomeUnknown
+}
+
+// belongsTo matches an event to a run by its labels, with unlabeled events belonging to every run
+func belongsTo(event itn.Event, run itn.Run) bool {
+	return (event.Region == "" || event.Region == run.Region) && (event.AccountID == "" || event.AccountID == run.AccountID)
+}
@@ -0,0 +1,143 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "state", "history.jsonl"))
+	records, err := store.List()
+	h.Ok(t, err)
+	h.Equals(t, 0, len(records))
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.Ok(t, store.Append(Record{ExperimentID: "EXP2", StartTime: start.Add(time.Hour), Outcome: OutcomeRunning}))
+	h.Ok(t, store.Append(Record{ExperimentID: "EXP1", StartTime: start, Outcome: OutcomeRunning}))
+	h.Ok(t, store.Append(Record{ExperimentID: "EXP2", StartTime: start.Add(time.Hour), Outcome: OutcomeCompleted}))
+
+	records, err = store.List()
+	h.Ok(t, err)
+	h.Equals(t, 2, len(records))
+	h.Equals(t, "EXP1", records[0].ExperimentID)
+	h.Equals(t, "EXP2", records[1].ExperimentID)
+	h.Equals(t, OutcomeCompleted, records[1].Outcome)
+
+	_, err = store.Get("EXP3")
+	h.Nok(t, err)
+}
+
+func TestWatcher(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	runs := []itn.Run{
+		{Region: "us-east-1", Experiment: experiment("EXP1", "i-1")},
+		{Region: "us-west-2", Experiment: experiment("EXP2", "i-2")},
+	}
+	events := make(chan itn.Event, 10)
+	watched := store.Watcher(itn.Options{Clean: true})(runs, events)
+
+	// both experiments are recorded as running as soon as they start
+	events <- itn.Event{Type: itn.EventRebalanceRecommendation, Message: "✅ Rebalance Recommendation sent", Region: "us-east-1"}
+	<-watched
+	records, err := store.List()
+	h.Ok(t, err)
+	h.Equals(t, 2, len(records))
+	h.Equals(t, OutcomeRunning, records[0].Outcome)
+
+	events <- itn.Event{Type: itn.EventShutdown, Message: "✅ Spot Instance Shutdown sent", Region: "us-east-1"}
+	events <- itn.Event{Type: itn.EventError, Message: "❌ Error executing: stopped", Region: "us-west-2"}
+	close(events)
+	for range watched {
+	}
+
+	record, err := store.Get("EXP1")
+	h.Ok(t, err)
+	h.Equals(t, OutcomeCompleted, record.Outcome)
+	h.Equals(t, []string{"i-1"}, record.InstanceIDs)
+	h.Equals(t, "15s", record.Settings.Delay)
+	h.Equals(t, true, record.Settings.Clean)
+	h.Equals(t, 2, len(record.Events))
+	h.Assert(t, record.EndTime != nil, "expected an end time")
+
+	record, err = store.Get("EXP2")
+	h.Ok(t, err)
+	h.Equals(t, OutcomeFailed, record.Outcome)
+	h.Equals(t, 1, len(record.Events))
+}
+
+func TestWatcherRecordFailed(t *testing.T) {
+	// the history can't be created under a file
+	dir := t.TempDir()
+	h.Ok(t, os.WriteFile(filepath.Join(dir, "state"), nil, 0o600))
+	store := NewStore(filepath.Join(dir, "state", "history.jsonl"))
+	events := make(chan itn.Event)
+	close(events)
+	var eventTypes []itn.EventType
+	for event := range store.Watcher(itn.Options{})([]itn.Run{{Region: "us-west-2", Experiment: experiment("EXP1", "i-1")}}, events) {
+		eventTypes = append(eventTypes, event.Type)
+	}
+	h.Equals(t, []itn.EventType{EventRecordFailed, EventRecordFailed}, eventTypes)
+}
+
+func TestExport(t *testing.T) {
+	end := time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)
+	records := []Record{{
+		ExperimentID: "EXP1",
+		Region:       "us-east-1",
+		InstanceIDs:  []string{"i-1", "i-2"},
+		Settings:     Settings{Delay: "15s", Clean: true},
+		StartTime:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:      &end,
+		Outcome:      OutcomeCompleted,
+	}}
+
+	var csv bytes.Buffer
+	h.Ok(t, Export(&csv, records, FormatCSV))
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	h.Equals(t, 2, len(lines))
+	h.Equals(t, "EXP1,,,us-east-1,i-1 i-2,15s,true,,,,2024-01-01T00:00:00Z,2024-01-01T00:05:00Z,completed", lines[1])
+
+	var out bytes.Buffer
+	h.Ok(t, Export(&out, records, FormatJSON))
+	var exported []Record
+	h.Ok(t, json.Unmarshal(out.Bytes(), &exported))
+	h.Equals(t, "EXP1", exported[0].ExperimentID)
+
+	h.Nok(t, Export(&out, records, "xml"))
+}
+
+func experiment(experimentID string, instanceID string) *types.Experiment {
+	return &types.Experiment{
+		Id:        aws.String(experimentID),
+		StartTime: aws.Time(time.Now()),
+		Actions: map[string]types.ExperimentAction{
+			"itn0": {ActionId: aws.String(itn.SpotITNAction), Parameters: map[string]string{"durationBeforeInterruption": "PT135S"}},
+		},
+		Targets: map[string]types.ExperimentTarget{
+			"itn0": {ResourceArns: []string{"arn:aws:ec2:us-east-1:12345:instance/" + instanceID}},
+		},
+	}
+}
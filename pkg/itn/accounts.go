@@ -0,0 +1,212 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+)
+
+// MultiAccount fans interruptions out to a MultiRegion per AWS account, using credentials from assuming
+// the same role in each account.
+type MultiAccount struct {
+	accounts map[string]*MultiRegion
+}
+
+func NewMultiAccount(cfg aws.Config, accountIDs []string, roleName string, regions []string) *MultiAccount {
+	stsClient := sts.NewFromConfig(cfg)
+	accounts := map[string]*MultiRegion{}
+	for _, accountID := range accountIDs {
+		accountCfg := cfg.Copy()
+		accountCfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient,
+			fmt.Sprintf("arn:aws:iam::%s:role/%s", accountID, roleName),
+			func(o *stscreds.AssumeRoleOptions) { o.RoleSessionName = "ec2-spot-interrupter" },
+		))
+		accounts[accountID] = NewMultiRegion(accountCfg, regions)
+	}
+	return &MultiAccount{accounts: accounts}
+}
+
+// AccountIDs returns the sorted IDs of the accounts the MultiAccount can interrupt instances in
+func (m MultiAccount) AccountIDs() []string {
+	accountIDs := lo.Keys(m.accounts)
+	sort.Strings(accountIDs)
+	return accountIDs
+}
+
+// Select returns the IDs of the running Spot instances with all of the tags in each account, grouped by account and region
+func (m MultiAccount) Select(ctx context.Context, tags map[string]string) (map[string]map[string][]string, error) {
+	targets := map[string]map[string][]string{}
+	for _, accountID := range m.AccountIDs() {
+		multiRegion := m.accounts[accountID]
+		if len(multiRegion.itns) == 0 {
+			return nil, fmt.Errorf("no regions specified")
+		}
+		// make sure the assumed role really belongs to the account, since its account ID is used to build the target ARNs
+		assumedAccountID, err := multiRegion.itns[multiRegion.Regions()[0]].getAccountID(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", accountID, err)
+		}
+		if assumedAccountID != accountID {
+			return nil, fmt.Errorf("%s: assumed role belongs to account %s", accountID, assumedAccountID)
+		}
+		byRegion, err := multiRegion.SpotInstancesWithTags(ctx, tags)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", accountID, err)
+		}
+		if len(byRegion) > 0 {
+			targets[accountID] = byRegion
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no running Spot instances with tags %v in accounts %v", tags, m.AccountIDs())
+	}
+	return targets, nil
+}
+
+// CheckGuardrails checks the instances of every account and region in targets against the guardrails, with
+// MaxInstances applying to the total across all the accounts
+func (m MultiAccount) CheckGuardrails(ctx context.Context, targets map[string]map[string][]string, guardrails Guardrails) error {
+	total := 0
+	for _, byRegion := range targets {
+		for _, instanceIDs := range byRegion {
+			total += len(instanceIDs)
+		}
+	}
+	err := guardrails.CheckCount(total)
+	perAccount := guardrails
+	perAccount.MaxInstances = 0
+	perAccount.AllowedAccounts = nil
+	for _, accountID := range lo.Intersect(m.AccountIDs(), lo.Keys(targets)) {
+		err = multierr.Append(err, guardrails.CheckAccount(accountID))
+		if accountErr := m.accounts[accountID].CheckGuardrails(ctx, targets[accountID], perAccount); accountErr != nil {
+			err = multierr.Append(err, fmt.Errorf("%s: %w", accountID, accountErr))
+		}
+	}
+	return err
+}
+
+// Preflight runs the pre-flight checks in each account and region of targets
+func (m MultiAccount) Preflight(ctx context.Context, targets map[string]map[string][]string) map[string]map[string]Checks {
+	checks := map[string]map[string]Checks{}
+	for accountID, byRegion := range targets {
+		if multiRegion, ok := m.accounts[accountID]; ok {
+			checks[accountID] = multiRegion.Preflight(ctx, byRegion)
+		}
+	}
+	return checks
+}
+
+// Interrupt interrupts the instances of targets, grouped by account and region, in every account concurrently.
+// The event streams of all the experiments are merged and each event is labeled with its account and region.
+// An account that fails to start is reported in the event stream as long as at least one other account started.
+func (m MultiAccount) Interrupt(ctx context.Context, targets map[string]map[string][]string, opts Options) ([]Run, <-chan Event, error) {
+	accountIDs := lo.Keys(targets)
+	sort.Strings(accountIDs)
+	for _, accountID := range accountIDs {
+		if _, ok := m.accounts[accountID]; !ok {
+			return nil, nil, fmt.Errorf("%s is not one of the accounts %v", accountID, m.AccountIDs())
+		}
+	}
+	type result struct {
+		runs   []Run
+		events <-chan Event
+		err    error
+	}
+	results := make([]result, len(accountIDs))
+	var wg sync.WaitGroup
+	for j, accountID := range accountIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runs, events, err := m.accounts[accountID].Interrupt(ctx, targets[accountID], opts)
+			for k := range runs {
+				runs[k].AccountID = accountID
+			}
+			results[j] = result{runs: runs, events: events, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var runs []Run
+	var streams []<-chan Event
+	var errs error
+	failures := make(chan Event, len(accountIDs))
+	for j, accountID := range accountIDs {
+		if results[j].err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("%s: %w", accountID, results[j].err))
+			failures <- Event{
+				Timestamp: time.Now(),
+				Type:      EventError,
+				Message:   fmt.Sprintf("❌ Error starting interruptions: %v", results[j].err),
+				AccountID: accountID,
+			}
+			continue
+		}
+		runs = append(runs, results[j].runs...)
+		streams = append(streams, withAccount(results[j].events, accountID))
+	}
+	close(failures)
+	if len(runs) == 0 {
+		return nil, nil, errs
+	}
+	return runs, Merge(append([]<-chan Event{failures}, streams...)...), nil
+}
+
+// AccountsInOrganizationalUnit returns the IDs of the active accounts in an AWS Organizations OU and all of its child OUs
+func AccountsInOrganizationalUnit(ctx context.Context, cfg aws.Config, ouID string) ([]string, error) {
+	return accountsInOrganizationalUnit(ctx, organizations.NewFromConfig(cfg), ouID)
+}
+
+func accountsInOrganizationalUnit(ctx context.Context, client organizationsAPI, ouID string) ([]string, error) {
+	var accountIDs []string
+	accounts := organizations.NewListAccountsForParentPaginator(client, &organizations.ListAccountsForParentInput{ParentId: aws.String(ouID)})
+	for accounts.HasMorePages() {
+		out, err := accounts.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range out.Accounts {
+			if account.State == orgtypes.AccountStateActive {
+				accountIDs = append(accountIDs, *account.Id)
+			}
+		}
+	}
+	ous := organizations.NewListOrganizationalUnitsForParentPaginator(client, &organizations.ListOrganizationalUnitsForParentInput{ParentId: aws.String(ouID)})
+	for ous.HasMorePages() {
+		out, err := ous.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, ou := range out.OrganizationalUnits {
+			childAccountIDs, err := accountsInOrganizationalUnit(ctx, client, *ou.Id)
+			if err != nil {
+				return nil, err
+			}
+			accountIDs = append(accountIDs, childAccountIDs...)
+		}
+	}
+	return accountIDs, nil
+}
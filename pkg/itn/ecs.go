@@ -0,0 +1,276 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/samber/lo"
+)
+
+const (
+	EventContainerInstanceDraining EventType = "ContainerInstanceDraining"
+	EventServiceRescheduled        EventType = "ServiceRescheduled"
+	EventTasksRescheduled          EventType = "TasksRescheduled"
+	EventECSDrainReport            EventType = "ECSDrainReport"
+)
+
+const (
+	ecsPollInterval = 5 * time.Second
+	// rescheduleTimeout is how long to wait for the tasks of interrupted container instances to be rescheduled once
+	// the experiments are done
+	rescheduleTimeout = 5 * time.Minute
+	// describeContainerInstancesLimit is the most container instances DescribeContainerInstances takes at a time
+	describeContainerInstancesLimit = 100
+)
+
+// ContainerInstance is an ECS container instance and the EC2 instance backing it
+type ContainerInstance struct {
+	ARN        string
+	InstanceID string
+}
+
+// ContainerInstances returns the container instances of the ECS cluster, only those of the capacity provider unless
+// it's empty
+func (i ITN) ContainerInstances(ctx context.Context, cluster string, capacityProvider string) ([]ContainerInstance, error) {
+	var instances []ContainerInstance
+	paginator := ecs.NewListContainerInstancesPaginator(i.ecsClient, &ecs.ListContainerInstancesInput{Cluster: aws.String(cluster)})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(out.ContainerInstanceArns) == 0 {
+			continue
+		}
+		described, err := i.ecsClient.DescribeContainerInstances(ctx, &ecs.DescribeContainerInstancesInput{
+			Cluster:            aws.String(cluster),
+			ContainerInstances: out.ContainerInstanceArns,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range described.ContainerInstances {
+			if capacityProvider != "" && lo.FromPtr(instance.CapacityProviderName) != capacityProvider {
+				continue
+			}
+			// external container instances, like those of ECS Anywhere, aren't backed by EC2 instances to interrupt
+			if instance.Ec2InstanceId == nil {
+				continue
+			}
+			instances = append(instances, ContainerInstance{ARN: *instance.ContainerInstanceArn, InstanceID: *instance.Ec2InstanceId})
+		}
+	}
+	if len(instances) == 0 {
+		if capacityProvider != "" {
+			return nil, fmt.Errorf("no container instances of capacity provider %s in cluster %s", capacityProvider, cluster)
+		}
+		return nil, fmt.Errorf("no container instances in cluster %s", cluster)
+	}
+	return instances, nil
+}
+
+// ecsDrain is what has happened to an interrupted container instance since the interruption notification
+type ecsDrain struct {
+	instance ContainerInstance
+	start    time.Time
+	// services are the number of tasks each service had running on the container instance at the notification
+	services    map[string]int
+	rescheduled map[string]bool
+	draining    time.Time
+	done        time.Time
+}
+
+// ECSDrainWatcher verifies the container instances of the ECS cluster are drained after their interruption
+// notification. It watches each container instance the runs interrupt for moving to DRAINING, and its service tasks
+// for being rescheduled on other container instances. The run fails if a container instance isn't DRAINING by the
+// time it's shut down, or its tasks aren't rescheduled.
+func (i ITN) ECSDrainWatcher(ctx context.Context, cluster string, instances []ContainerInstance, opts PollOptions) Watcher {
+	return func(runs []Run, events <-chan Event) <-chan Event {
+		out := make(chan Event, 10)
+		// container instances skipped by validation aren't interrupted, so they aren't expected to drain
+		interrupted := lo.FlatMap(runs, func(run Run, _ int) []string { return run.InstanceIDs })
+		instances := lo.Filter(instances, func(instance ContainerInstance, _ int) bool { return lo.Contains(interrupted, instance.InstanceID) })
+		go func() {
+			defer close(out)
+			var drains []*ecsDrain
+			var mu sync.Mutex
+			// claimed are the replacement tasks already counted for a container instance, so each is counted once
+			claimed := map[string]bool{}
+			poller := NewPoller(opts.WithDefaults(ecsPollInterval, rescheduleTimeout))
+			for event := range events {
+				if event.Type == EventInterruptionNotification && drains == nil {
+					var err error
+					if drains, err = i.ecsDrains(ctx, cluster, instances, event.Timestamp); err != nil {
+						out <- event
+						out <- Event{Type: EventError, Message: fmt.Sprintf("❌ Watching container instances: %s", err), Timestamp: time.Now()}
+						continue
+					}
+					poller.Go(ctx, func() (bool, error) { return i.pollECS(ctx, cluster, drains, claimed, &mu, out) }, func(err error) {
+						out <- Event{Type: EventError, Message: fmt.Sprintf("❌ Watching container instances: %s", err), Timestamp: time.Now()}
+					})
+				}
+				out <- event
+				if event.Type == EventShutdown {
+					mu.Lock()
+					for _, d := range drains {
+						if d.draining.IsZero() {
+							out <- Event{Type: EventError, Message: fmt.Sprintf("❌ Container instance %s wasn't DRAINING before shutdown", d.instance.InstanceID), Timestamp: time.Now()}
+						}
+					}
+					mu.Unlock()
+				}
+			}
+			if drains == nil {
+				return
+			}
+			poller.Wait()
+			for _, d := range drains {
+				if d.done.IsZero() {
+					out <- Event{Type: EventError, Message: fmt.Sprintf("❌ Tasks of container instance %s weren't rescheduled", d.instance.InstanceID), Timestamp: time.Now()}
+				}
+				out <- Event{Type: EventECSDrainReport, Message: d.report(), Timestamp: time.Now()}
+			}
+		}()
+		return out
+	}
+}
+
+// ecsDrains counts the service tasks running on each container instance at the interruption notification
+func (i ITN) ecsDrains(ctx context.Context, cluster string, instances []ContainerInstance, start time.Time) ([]*ecsDrain, error) {
+	var drains []*ecsDrain
+	for _, instance := range instances {
+		tasks, err := i.tasks(ctx, &ecs.ListTasksInput{Cluster: aws.String(cluster), ContainerInstance: aws.String(instance.ARN)})
+		if err != nil {
+			return nil, err
+		}
+		services := map[string]int{}
+		for _, task := range tasks {
+			// standalone tasks aren't rescheduled, only those of services, which are grouped as service:name
+			if service, ok := strings.CutPrefix(lo.FromPtr(task.Group), "service:"); ok {
+				services[service]++
+			}
+		}
+		drains = append(drains, &ecsDrain{instance: instance, start: start, services: services, rescheduled: map[string]bool{}})
+	}
+	return drains, nil
+}
+
+// pollECS updates the drains, returning whether they're all done
+func (i ITN) pollECS(ctx context.Context, cluster string, drains []*ecsDrain, claimed map[string]bool, mu *sync.Mutex, out chan<- Event) (bool, error) {
+	interrupted := lo.Map(drains, func(d *ecsDrain, _ int) string { return d.instance.ARN })
+	statuses := map[string]string{}
+	for _, arns := range lo.Chunk(interrupted, describeContainerInstancesLimit) {
+		described, err := i.ecsClient.DescribeContainerInstances(ctx, &ecs.DescribeContainerInstancesInput{
+			Cluster:            aws.String(cluster),
+			ContainerInstances: arns,
+		})
+		if err != nil {
+			return false, err
+		}
+		for _, instance := range described.ContainerInstances {
+			statuses[*instance.ContainerInstanceArn] = lo.FromPtr(instance.Status)
+		}
+	}
+	replacements := map[string][]ecstypes.Task{}
+	mu.Lock()
+	defer mu.Unlock()
+	now := time.Now()
+	for _, d := range drains {
+		if d.draining.IsZero() && statuses[d.instance.ARN] == string(ecstypes.ContainerInstanceStatusDraining) {
+			d.draining = now
+			out <- Event{Type: EventContainerInstanceDraining, Message: fmt.Sprintf("🚧 Container instance %s is DRAINING %s after the interruption notification", d.instance.InstanceID, Since(d.start, now)), Timestamp: now}
+		}
+		if d.draining.IsZero() || !d.done.IsZero() {
+			continue
+		}
+		for _, service := range lo.Keys(d.services) {
+			if d.rescheduled[service] {
+				continue
+			}
+			tasks, ok := replacements[service]
+			if !ok {
+				var err error
+				if tasks, err = i.tasks(ctx, &ecs.ListTasksInput{Cluster: aws.String(cluster), ServiceName: aws.String(service)}); err != nil {
+					return false, err
+				}
+				replacements[service] = tasks
+			}
+			// the service's tasks started since the notification on container instances that aren't interrupted replace
+			// the ones here, unless they've already been counted as replacing those of another container instance
+			started := lo.Filter(tasks, func(task ecstypes.Task, _ int) bool {
+				return lo.FromPtr(task.LastStatus) == "RUNNING" && !lo.Contains(interrupted, lo.FromPtr(task.ContainerInstanceArn)) &&
+					!lo.FromPtr(task.CreatedAt).Before(d.start) && !claimed[*task.TaskArn]
+			})
+			if len(started) >= d.services[service] {
+				for _, task := range started[:d.services[service]] {
+					claimed[*task.TaskArn] = true
+				}
+				d.rescheduled[service] = true
+				out <- Event{Type: EventServiceRescheduled, Message: fmt.Sprintf("🔁 %d tasks of service %s rescheduled off container instance %s %s after the interruption notification", d.services[service], service, d.instance.InstanceID, Since(d.start, now)), Timestamp: now}
+			}
+		}
+		if len(d.rescheduled) == len(d.services) {
+			d.done = now
+			out <- Event{Type: EventTasksRescheduled, Message: fmt.Sprintf("✅ Tasks of container instance %s rescheduled %s after the interruption notification", d.instance.InstanceID, Since(d.start, now)), Timestamp: now}
+		}
+	}
+	return lo.EveryBy(drains, func(d *ecsDrain) bool { return !d.done.IsZero() }), nil
+}
+
+// tasks lists and describes the running tasks
+func (i ITN) tasks(ctx context.Context, input *ecs.ListTasksInput) ([]ecstypes.Task, error) {
+	input.DesiredStatus = ecstypes.DesiredStatusRunning
+	var tasks []ecstypes.Task
+	paginator := ecs.NewListTasksPaginator(i.ecsClient, input)
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(out.TaskArns) == 0 {
+			continue
+		}
+		// DescribeTasks takes up to 100 tasks, the most ListTasks returns at a time
+		described, err := i.ecsClient.DescribeTasks(ctx, &ecs.DescribeTasksInput{Cluster: input.Cluster, Tasks: out.TaskArns})
+		if err != nil {
+			return nil, err
+		}
+		if len(described.Failures) > 0 {
+			return nil, errors.New(lo.FromPtr(described.Failures[0].Reason))
+		}
+		tasks = append(tasks, described.Tasks...)
+	}
+	return tasks, nil
+}
+
+// report summarizes the time from the interruption notification to each step of the drain
+func (d *ecsDrain) report() string {
+	services := "no services"
+	if len(d.services) > 0 {
+		names := lo.Keys(d.services)
+		sort.Strings(names)
+		services = "services " + strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("📋 Container instance %s (%s): %s, %s", d.instance.InstanceID, services, Milestone("DRAINING", d.start, d.draining), Milestone("rescheduled", d.start, d.done))
+}
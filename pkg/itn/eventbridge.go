@@ -0,0 +1,166 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/samber/lo"
+)
+
+const (
+	// EventSource is the source of the events published to EventBridge
+	EventSource = "ec2-spot-interrupter"
+	// EventDetailType is the detail type of the events published to EventBridge
+	EventDetailType = "Spot Interruption Lifecycle Transition"
+
+	EventPublishFailed EventType = "PublishFailed"
+)
+
+// lifecycleEvents are the transitions of an interruption published to EventBridge
+var lifecycleEvents = []EventType{
+	EventExperimentPending,
+	EventExperimentInitiating,
+	EventRebalanceRecommendation,
+	EventInterruptionScheduled,
+	EventInterruptionNotification,
+	EventShutdown,
+	EventError,
+}
+
+// Transition is the detail of an event published to EventBridge
+type Transition struct {
+	Transition   EventType `json:"transition"`
+	Message      string    `json:"message"`
+	ExperimentID string    `json:"experimentId"`
+	InstanceIDs  []string  `json:"instanceIds"`
+	AccountID    string    `json:"accountId"`
+	Region       string    `json:"region"`
+	Time         time.Time `json:"time"`
+	// StartTime is when the experiment started, and ElapsedSeconds how long before the transition that was
+	StartTime      *time.Time `json:"startTime,omitempty"`
+	ElapsedSeconds float64    `json:"elapsedSeconds"`
+	// NextEventSeconds is how long until the next transition is expected, if it's known
+	NextEventSeconds float64 `json:"nextEventSeconds,omitempty"`
+}
+
+// EventBridgeWatcher publishes each lifecycle transition of the runs to the event bus, like the interruption
+// notification, so other systems can correlate interruptions with their alarms. Repeats of a transition, like the
+// experiment still pending, are only published once. Events that fail to publish are reported without failing the
+// run.
+func (i ITN) EventBridgeWatcher(ctx context.Context, eventBus string) Watcher {
+	return func(runs []Run, events <-chan Event) <-chan Event {
+		out := make(chan Event, 10)
+		queue := make(chan Event, 100)
+		done := make(chan struct{})
+		// the account of a single account run is the interrupter's own, which is looked up once and isn't worth
+		// failing the events over
+		accountID := sync.OnceValue(func() string {
+			accountID, _ := i.AccountID(ctx)
+			return accountID
+		})
+		go func() {
+			defer close(done)
+			last := map[string]EventType{}
+			for event := range queue {
+				transition, ok := i.transition(runs, event, accountID)
+				if !ok || last[transition.ExperimentID] == event.Type {
+					continue
+				}
+				last[transition.ExperimentID] = event.Type
+				if err := i.publish(ctx, eventBus, transition); err != nil {
+					out <- Event{
+						Type:      EventPublishFailed,
+						Message:   fmt.Sprintf("⚠️ Publishing %s of %s to EventBridge failed: %s", event.Type, transition.ExperimentID, err),
+						Timestamp: time.Now(),
+						Region:    event.Region,
+						AccountID: event.AccountID,
+					}
+				}
+			}
+		}()
+		go func() {
+			defer close(out)
+			for event := range events {
+				if lo.Contains(lifecycleEvents, event.Type) {
+					queue <- event
+				}
+				out <- event
+			}
+			close(queue)
+			<-done
+		}()
+		return out
+	}
+}
+
+// transition describes the event with the run it came from, matched by its region and account
+func (i ITN) transition(runs []Run, event Event, ownAccountID func() string) (Transition, bool) {
+	run, ok := lo.Find(runs, func(run Run) bool {
+		return run.Experiment != nil && (event.Region == "" || run.Region == event.Region) && run.AccountID == event.AccountID
+	})
+	if !ok {
+		return Transition{}, false
+	}
+	accountID := lo.CoalesceOrEmpty(run.AccountID, ownAccountID())
+	// experiments of persistent templates select the instances by tags rather than ARN
+	instanceIDs := lo.CoalesceSliceOrEmpty(run.InstanceIDs, ExperimentInstanceIDs(*run.Experiment))
+	transition := Transition{
+		Transition:       event.Type,
+		Message:          event.Message,
+		ExperimentID:     aws.ToString(run.Experiment.Id),
+		InstanceIDs:      lo.Ternary(instanceIDs == nil, []string{}, instanceIDs),
+		AccountID:        accountID,
+		Region:           lo.CoalesceOrEmpty(run.Region, i.Region()),
+		Time:             event.Timestamp,
+		StartTime:        lo.CoalesceOrEmpty(run.Experiment.StartTime, run.Experiment.CreationTime),
+		NextEventSeconds: event.NextEvent.Seconds(),
+	}
+	if transition.StartTime != nil {
+		transition.ElapsedSeconds = event.Timestamp.Sub(*transition.StartTime).Seconds()
+	}
+	return transition, true
+}
+
+func (i ITN) publish(ctx context.Context, eventBus string, transition Transition) error {
+	detail, err := json.Marshal(transition)
+	if err != nil {
+		return err
+	}
+	entry := eventbridgetypes.PutEventsRequestEntry{
+		EventBusName: aws.String(eventBus),
+		Source:       aws.String(EventSource),
+		DetailType:   aws.String(EventDetailType),
+		Detail:       aws.String(string(detail)),
+		Time:         aws.Time(transition.Time),
+	}
+	if transition.AccountID != "" {
+		entry.Resources = i.instanceIDsToARNs(transition.InstanceIDs, transition.Region, transition.AccountID)
+	}
+	out, err := i.eventBridgeClient.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: []eventbridgetypes.PutEventsRequestEntry{entry}})
+	if err != nil {
+		return err
+	}
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		return fmt.Errorf("%s: %s", aws.ToString(out.Entries[0].ErrorCode), aws.ToString(out.Entries[0].ErrorMessage))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+)
+
+const asgNameTag = "aws:autoscaling:groupName"
+
+// Guardrails protect against interrupting more capacity than intended, like when running against the wrong profile.
+// Zero values disable the corresponding guardrail.
+type Guardrails struct {
+	// ProtectedTags are tags that exclude an instance from being interrupted if it has any of them
+	ProtectedTags map[string]string
+	// MaxInstances is the maximum number of instances interrupted in a single run
+	MaxInstances int
+	// MaxASGFraction is the maximum fraction of the running instances of any Auto Scaling group interrupted in a single run
+	MaxASGFraction float64
+	// AllowedAccounts are the only account IDs that instances may be interrupted in
+	AllowedAccounts []string
+}
+
+// CheckCount checks the total number of instances of a run against MaxInstances
+func (g Guardrails) CheckCount(count int) error {
+	if g.MaxInstances > 0 && count > g.MaxInstances {
+		return fmt.Errorf("%d instances exceeds the maximum of %d", count, g.MaxInstances)
+	}
+	return nil
+}
+
+// CheckAccount checks an account ID against AllowedAccounts
+func (g Guardrails) CheckAccount(accountID string) error {
+	if len(g.AllowedAccounts) > 0 && !lo.Contains(g.AllowedAccounts, accountID) {
+		return fmt.Errorf("account %s is not one of the allowed accounts %v", accountID, g.AllowedAccounts)
+	}
+	return nil
+}
+
+// CheckGuardrails checks the instances against all of the guardrails, returning an error for every violation
+func (i ITN) CheckGuardrails(ctx context.Context, instanceIDs []string, guardrails Guardrails) error {
+	err := guardrails.CheckCount(len(instanceIDs))
+	if len(guardrails.AllowedAccounts) > 0 {
+		accountID, accountErr := i.getAccountID(ctx)
+		if accountErr != nil {
+			return accountErr
+		}
+		err = multierr.Append(err, guardrails.CheckAccount(accountID))
+	}
+	return multierr.Append(err, i.checkInstanceGuardrails(ctx, instanceIDs, guardrails))
+}
+
+func (i ITN) checkInstanceGuardrails(ctx context.Context, instanceIDs []string, guardrails Guardrails) error {
+	if len(guardrails.ProtectedTags) == 0 && guardrails.MaxASGFraction <= 0 {
+		return nil
+	}
+	instances, err := i.describeInstances(ctx, instanceIDs)
+	if err != nil {
+		return err
+	}
+	asgTargets := map[string]int{}
+	for _, instance := range instances {
+		for _, tag := range instance.Tags {
+			if value, ok := guardrails.ProtectedTags[*tag.Key]; ok && value == aws.ToString(tag.Value) {
+				err = multierr.Append(err, fmt.Errorf("%s is protected by tag %s=%s", *instance.InstanceId, *tag.Key, value))
+			}
+			if *tag.Key == asgNameTag {
+				asgTargets[*tag.Value]++
+			}
+		}
+	}
+	if guardrails.MaxASGFraction <= 0 {
+		return err
+	}
+	asgNames := lo.Keys(asgTargets)
+	sort.Strings(asgNames)
+	for _, asgName := range asgNames {
+		running, countErr := i.countASGInstances(ctx, asgName)
+		if countErr != nil {
+			return multierr.Append(err, countErr)
+		}
+		if fraction := float64(asgTargets[asgName]) / float64(running); running > 0 && fraction > guardrails.MaxASGFraction {
+			err = multierr.Append(err, fmt.Errorf("%d of the %d running instances of Auto Scaling group %s exceeds the maximum fraction of %.2f",
+				asgTargets[asgName], running, asgName, guardrails.MaxASGFraction))
+		}
+	}
+	return err
+}
+
+// countASGInstances counts the pending and running instances of an Auto Scaling group by the tag it adds to its instances
+func (i ITN) countASGInstances(ctx context.Context, asgName string) (int, error) {
+	paginator := ec2.NewDescribeInstancesPaginator(i.ec2Client, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String(fmt.Sprintf("tag:%s", asgNameTag)), Values: []string{asgName}},
+			{Name: aws.String("instance-state-name"), Values: []string{string(ec2types.InstanceStateNamePending), string(ec2types.InstanceStateNameRunning)}},
+		},
+	})
+	count := 0
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, r := range out.Reservations {
+			count += len(r.Instances)
+		}
+	}
+	return count, nil
+}
+
+// CheckGuardrails checks the instances of every region in targets against the guardrails, with MaxInstances
+// applying to the total across all the regions
+func (m MultiRegion) CheckGuardrails(ctx context.Context, targets map[string][]string, guardrails Guardrails) error {
+	total := lo.Sum(lo.Map(lo.Values(targets), func(instanceIDs []string, _ int) int { return len(instanceIDs) }))
+	err := guardrails.CheckCount(total)
+	regional := guardrails
+	regional.MaxInstances = 0
+	for _, region := range lo.Intersect(m.Regions(), lo.Keys(targets)) {
+		if regionErr := m.itns[region].CheckGuardrails(ctx, targets[region], regional); regionErr != nil {
+			err = multierr.Append(err, fmt.Errorf("%s: %w", region, regionErr))
+		}
+	}
+	return err
+}
@@ -17,19 +17,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 	"github.com/aws/aws-sdk-go-v2/service/fis"
 	"github.com/aws/aws-sdk-go-v2/service/fis/types"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/aws/smithy-go/ptr"
-	"go.uber.org/multierr"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
 )
 
 const (
@@ -60,102 +66,218 @@ const (
 			}
 		]
 	}`
-	SpotITNAction  = "aws:ec2:send-spot-instance-interruptions"
-	fisRoleName    = "aws-fis-itn"
-	fisTargetLimit = 5
+	SpotITNAction   = "aws:ec2:send-spot-instance-interruptions"
+	fisRoleName     = "aws-fis-itn"
+	fisTargetLimit  = 5
+	createdByTag    = "ec2-spot-interrupter/created-by"
+	templateNameTag = "ec2-spot-interrupter/template"
 )
 
+// Mode is how the experiment template of an interruption is managed
+type Mode string
+
+const (
+	// ModeEphemeral creates an experiment template for every interruption
+	ModeEphemeral Mode = "ephemeral"
+	// ModePersistent creates a named experiment template selecting the instances by tags once, and reuses it for
+	// every interruption. The tags must select exactly the validated instances, and no more
+	// than a single FIS action can target.
+	ModePersistent Mode = "persistent"
+)
+
+// Modes are the supported modes
+var Modes = []Mode{ModeEphemeral, ModePersistent}
+
+// Options configure the experiment started by Interrupt
+type Options struct {
+	// Delay is the time until the interruption notification is sent
+	Delay time.Duration
+	// Clean deletes the experiment template once the experiment is done
+	Clean bool
+	Mode  Mode
+	// RoleARN is the IAM role FIS runs the experiment as, instead of the role created by the interrupter
+	RoleARN string
+	// StopAlarms are the ARNs of CloudWatch alarms that stop the experiment when any of them is in alarm
+	StopAlarms []string
+	// Tags are the tags the instances interrupted by the template of ModePersistent are selected by
+	Tags map[string]string
+	// TemplateName identifies the template in ModePersistent
+	TemplateName string
+}
+
+// experimentPollInterval is how often the state of an experiment is checked while monitoring it by default
+const experimentPollInterval = 5 * time.Second
+
 type ITN struct {
-	cfg       aws.Config
-	stsClient stsAPI
-	fisClient fisAPI
-	iamClient iamAPI
-	ec2Client ec2API
+	cfg               aws.Config
+	stsClient         stsAPI
+	fisClient         fisAPI
+	iamClient         iamAPI
+	ec2Client         ec2API
+	ecsClient         ecsAPI
+	eventBridgeClient eventBridgeAPI
+	quotasClient      serviceQuotasAPI
+	// pollInterval overrides experimentPollInterval
+	pollInterval time.Duration
 }
 
+// New returns an ITN calling AWS with the config. The calls of its clients are traced as child spans of the
+// interruption lifecycle.
 func New(cfg aws.Config) *ITN {
+	// clone the API options so that the middlewares aren't appended to the caller's config
+	cfg.APIOptions = slices.Clone(cfg.APIOptions)
+	otelaws.AppendMiddlewares(&cfg.APIOptions)
 	return &ITN{
-		cfg:       cfg,
-		stsClient: sts.NewFromConfig(cfg),
-		fisClient: fis.NewFromConfig(cfg),
-		iamClient: iam.NewFromConfig(cfg),
-		ec2Client: ec2.NewFromConfig(cfg),
+		cfg:               cfg,
+		stsClient:         sts.NewFromConfig(cfg),
+		fisClient:         fis.NewFromConfig(cfg),
+		iamClient:         iam.NewFromConfig(cfg),
+		ec2Client:         ec2.NewFromConfig(cfg),
+		ecsClient:         ecs.NewFromConfig(cfg),
+		eventBridgeClient: eventbridge.NewFromConfig(cfg),
+		quotasClient:      servicequotas.NewFromConfig(cfg),
 	}
 }
 
-// Interrupt will start an FIS experiment to send Spot ITNs to the instance IDs specified and then monitor
-// the experiment for the progress.
-func (i ITN) Interrupt(ctx context.Context, instanceIDs []string, delay time.Duration, clean bool) (*types.Experiment, <-chan Event, error) {
-	if err := i.validate(ctx, instanceIDs); err != nil {
-		return nil, nil, err
+// Region returns the AWS Region the ITN sends interruptions in
+func (i ITN) Region() string {
+	return i.cfg.Region
+}
+
+// Profile returns the shared config profile the ITN's credentials were loaded from, or "" if they weren't
+func (i ITN) Profile() string {
+	for _, source := range i.cfg.ConfigSources {
+		if shared, ok := source.(awsconfig.SharedConfig); ok {
+			return shared.Profile
+		}
 	}
-	experiment, err := i.createInterruptions(ctx, instanceIDs, delay)
+	return ""
+}
+
+// Interrupt will start an FIS experiment to send Spot ITNs to the instance IDs specified and then monitor
+// the experiment for the progress. Each interruption is traced by an Interrupt span that ends once the experiment is
+// monitored and cleaned up.
+func (i ITN) Interrupt(ctx context.Context, instanceIDs []string, opts Options) (*types.Experiment, <-chan Event, error) {
+	return i.interrupt(ctx, instanceIDs, opts, false)
+}
+
+// interrupt is Interrupt, skipping the validation of the instances when the caller already validated them
+func (i ITN) interrupt(ctx context.Context, instanceIDs []string, opts Options, validated bool) (*types.Experiment, <-chan Event, error) {
+	ctx, span := startSpan(ctx, "Interrupt", InstanceIDsAttribute.StringSlice(instanceIDs))
+	experiment, err := i.start(ctx, instanceIDs, opts, validated)
 	if err != nil {
+		endSpan(span, err)
 		return nil, nil, err
 	}
+	span.SetAttributes(ExperimentIDAttribute.String(aws.ToString(experiment.Id)))
 	events := make(chan Event, 10)
 	go func() {
 		defer close(events)
-		if clean {
-			defer func() {
-				if err := i.Clean(ctx, *experiment); err != nil {
-					events <- Event{
-						Timestamp: time.Now(),
-						Message:   fmt.Sprintf("❌ Error cleaning up FIS Experiment: %v", err),
-					}
-				}
-			}()
-		}
-		if err := i.monitor(ctx, events, experiment, delay); err != nil {
+		err := i.monitor(ctx, events, experiment, opts.Delay)
+		if err != nil {
 			events <- Event{
 				Timestamp: time.Now(),
+				Type:      EventError,
 				Message:   fmt.Sprintf("❌ Error executing: %v", err),
 			}
 		}
+		if opts.Clean {
+			if cleanErr := i.Clean(ctx, *experiment); cleanErr != nil {
+				events <- Event{
+					Timestamp: time.Now(),
+					Type:      EventError,
+					Message:   fmt.Sprintf("❌ Error cleaning up FIS Experiment: %v", cleanErr),
+				}
+				err = errors.Join(err, cleanErr)
+			}
+		}
+		endSpan(span, err)
 	}()
 	return experiment, events, nil
 }
 
-func (i ITN) validate(ctx context.Context, instanceIDs []string) error {
-	if len(instanceIDs) == 0 {
-		return errors.New("no instances specified")
+// start validates the instances, unless they were already validated, and starts the experiment interrupting them
+func (i ITN) start(ctx context.Context, instanceIDs []string, opts Options, validated bool) (*types.Experiment, error) {
+	if opts.Mode != "" && !lo.Contains(Modes, opts.Mode) {
+		return nil, fmt.Errorf("unsupported mode %q", opts.Mode)
 	}
-	paginator := ec2.NewDescribeInstancesPaginator(i.ec2Client, &ec2.DescribeInstancesInput{InstanceIds: instanceIDs})
-	var instances []ec2types.Instance
-	for paginator.HasMorePages() {
-		out, err := paginator.NextPage(ctx)
-		if err != nil {
-			return err
-		}
-		for _, r := range out.Reservations {
-			instances = append(instances, r.Instances...)
-		}
+	if opts.Mode == ModePersistent && (len(opts.Tags) == 0 || opts.TemplateName == "") {
+		return nil, errors.New("persistent mode targets instances by tags and requires a template name")
 	}
-	var err error
-	for _, instance := range instances {
-		if instance.InstanceLifecycle != ec2types.InstanceLifecycleTypeSpot {
-			err = multierr.Append(err, fmt.Errorf("%s is not a Spot instance", *instance.InstanceId))
-		}
-		if instance.State.Name != ec2types.InstanceStateNameRunning {
-			err = multierr.Append(err, fmt.Errorf("%s is not running", *instance.InstanceId))
+	if !validated {
+		if err := i.validate(ctx, instanceIDs); err != nil {
+			return nil, err
 		}
 	}
+	return i.createInterruptions(ctx, instanceIDs, opts)
+}
+
+// Experiment returns an existing experiment
+func (i ITN) Experiment(ctx context.Context, experimentID string) (*types.Experiment, error) {
+	out, err := i.fisClient.GetExperiment(ctx, &fis.GetExperimentInput{Id: aws.String(experimentID)})
+	if err != nil {
+		return nil, err
+	}
+	return out.Experiment, nil
+}
+
+// Attach monitors an existing experiment without creating or cleaning up anything, like one started by someone else
+// or by a run of the CLI that exited. The delay is inferred from the experiment's durationBeforeInterruption.
+func (i ITN) Attach(ctx context.Context, experimentID string) (*types.Experiment, <-chan Event, error) {
+	ctx, span := startSpan(ctx, "Attach", ExperimentIDAttribute.String(experimentID))
+	experiment, err := i.Experiment(ctx, experimentID)
+	if err != nil {
+		endSpan(span, err)
+		return nil, nil, err
+	}
+	delay, err := ExperimentDelay(experiment)
+	if err != nil {
+		endSpan(span, err)
+		return nil, nil, err
+	}
+	events := make(chan Event, 10)
+	go func() {
+		defer close(events)
+		err := i.monitor(ctx, events, experiment, delay)
+		if err != nil {
+			events <- Event{
+				Timestamp: time.Now(),
+				Type:      EventError,
+				Message:   fmt.Sprintf("❌ Error executing: %v", err),
+			}
+		}
+		endSpan(span, err)
+	}()
+	return experiment, events, nil
+}
+
+func (i ITN) validate(ctx context.Context, instanceIDs []string) (err error) {
+	ctx, span := startSpan(ctx, "validate", InstanceIDsAttribute.StringSlice(instanceIDs))
+	defer func() { endSpan(span, err) }()
+	_, err = i.Validate(ctx, instanceIDs)
 	return err
 }
 
 func (i ITN) SpotInstances(ctx context.Context) ([]ec2types.Instance, error) {
-	paginator := ec2.NewDescribeInstancesPaginator(i.ec2Client, &ec2.DescribeInstancesInput{
-		Filters: []ec2types.Filter{
-			{
-				Name:   aws.String("instance-lifecycle"),
-				Values: []string{string(ec2types.InstanceLifecycleSpot)},
-			},
-			{
-				Name:   aws.String("instance-state-name"),
-				Values: []string{string(ec2types.InstanceStateNameRunning)},
-			},
+	return i.SpotInstancesWithTags(ctx, nil)
+}
+
+// SpotInstancesWithTags returns the running Spot instances that have all of the tags
+func (i ITN) SpotInstancesWithTags(ctx context.Context, tags map[string]string) ([]ec2types.Instance, error) {
+	filters := []ec2types.Filter{
+		{
+			Name:   aws.String("instance-lifecycle"),
+			Values: []string{string(ec2types.InstanceLifecycleSpot)},
 		},
-	})
+		{
+			Name:   aws.String("instance-state-name"),
+			Values: []string{string(ec2types.InstanceStateNameRunning)},
+		},
+	}
+	for key, value := range tags {
+		filters = append(filters, ec2types.Filter{Name: aws.String(fmt.Sprintf("tag:%s", key)), Values: []string{value}})
+	}
+	paginator := ec2.NewDescribeInstancesPaginator(i.ec2Client, &ec2.DescribeInstancesInput{Filters: filters})
 	var instances []ec2types.Instance
 	for paginator.HasMorePages() {
 		out, err := paginator.NextPage(ctx)
@@ -169,114 +291,323 @@ func (i ITN) SpotInstances(ctx context.Context) ([]ec2types.Instance, error) {
 	return instances, nil
 }
 
-// Clean deletes the generated experiment template from FIS
-func (i ITN) Clean(ctx context.Context, experiment types.Experiment) error {
-	_, err := i.fisClient.DeleteExperimentTemplate(ctx, &fis.DeleteExperimentTemplateInput{Id: experiment.ExperimentTemplateId})
+// SpotInstanceRequest returns the Spot request the instance was launched by, or nil if it wasn't launched by one
+func (i ITN) SpotInstanceRequest(ctx context.Context, instance ec2types.Instance) (*ec2types.SpotInstanceRequest, error) {
+	if instance.SpotInstanceRequestId == nil {
+		return nil, nil
+	}
+	out, err := i.ec2Client.DescribeSpotInstanceRequests(ctx, &ec2.DescribeSpotInstanceRequestsInput{
+		SpotInstanceRequestIds: []string{*instance.SpotInstanceRequestId},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.SpotInstanceRequests) == 0 {
+		return nil, nil
+	}
+	return &out.SpotInstanceRequests[0], nil
+}
+
+// InstanceStates returns the state of each of the instances that exists, like running or shutting-down
+func (i ITN) InstanceStates(ctx context.Context, instanceIDs []string) (map[string]string, error) {
+	instances, err := i.describeInstances(ctx, instanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	states := map[string]string{}
+	for _, instance := range instances {
+		if instance.State != nil {
+			states[*instance.InstanceId] = string(instance.State.Name)
+		}
+	}
+	return states, nil
+}
+
+// Clean deletes the generated experiment template from FIS. Persistent templates are kept for the next interruption.
+func (i ITN) Clean(ctx context.Context, experiment types.Experiment) (err error) {
+	ctx, span := startSpan(ctx, "Clean", ExperimentIDAttribute.String(aws.ToString(experiment.Id)))
+	defer func() { endSpan(span, err) }()
+	if _, ok := experiment.Tags[templateNameTag]; ok {
+		return nil
+	}
+	_, err = i.fisClient.DeleteExperimentTemplate(ctx, &fis.DeleteExperimentTemplateInput{Id: experiment.ExperimentTemplateId})
 	return err
 }
 
+// EventType identifies the kind of an event, independent of its message
+type EventType string
+
+const (
+	EventRebalanceRecommendation  EventType = "RebalanceRecommendation"
+	EventInterruptionScheduled    EventType = "InterruptionScheduled"
+	EventExperimentPending        EventType = "ExperimentPending"
+	EventExperimentInitiating     EventType = "ExperimentInitiating"
+	EventInterruptionNotification EventType = "InterruptionNotification"
+	EventShutdown                 EventType = "Shutdown"
+	EventError                    EventType = "Error"
+)
+
 type Event struct {
+	Type      EventType
 	Message   string
 	NextEvent time.Duration
 	Timestamp time.Time
+	// Region and AccountID are set on events that have been merged from multiple regions or accounts
+	Region    string
+	AccountID string
 }
 
-func (i ITN) monitor(ctx context.Context, events chan Event, experiment *types.Experiment, delay time.Duration) error {
+// Scope labels where an event came from when it has been merged from multiple regions or accounts
+func (e Event) Scope() string {
+	if e.AccountID != "" && e.Region != "" {
+		return fmt.Sprintf("%s/%s", e.AccountID, e.Region)
+	}
+	return e.AccountID + e.Region
+}
+
+// Watcher observes the event stream of runs. It passes every event through, adds events of its own, and closes the
+// stream it returns once events is closed and it's done.
+type Watcher func(runs []Run, events <-chan Event) <-chan Event
+
+// Watch passes events through each of the watchers in order
+func Watch(runs []Run, events <-chan Event, watchers ...Watcher) <-chan Event {
+	for _, watcher := range watchers {
+		events = watcher(runs, events)
+	}
+	return events
+}
+
+// monitor reports the progress of the experiment until the instances shut down. Each of its phases is traced by a
+// span: waiting for the interruption, polling the experiment until it completes, and waiting for the shutdown.
+func (i ITN) monitor(ctx context.Context, events chan Event, experiment *types.Experiment, delay time.Duration) (err error) {
+	ctx, span := startSpan(ctx, "monitor", ExperimentIDAttribute.String(aws.ToString(experiment.Id)))
+	defer func() { endSpan(span, err) }()
 	events <- Event{
 		Timestamp: time.Now(),
+		Type:      EventRebalanceRecommendation,
 		Message:   "✅ Rebalance Recommendation sent",
 	}
-	if experiment.StartTime != nil && time.Until(*experiment.StartTime) < delay {
-		timeUntilStart := delay - time.Until(*experiment.StartTime)
-		events <- Event{
-			Message:   fmt.Sprintf("⏳ Interruption will be sent in %d seconds", int(timeUntilStart.Seconds())),
-			NextEvent: timeUntilStart,
-			Timestamp: time.Now(),
+	if experiment.StartTime != nil {
+		if timeUntilInterruption := delay - time.Since(*experiment.StartTime); timeUntilInterruption > 0 {
+			events <- Event{
+				Type:      EventInterruptionScheduled,
+				Message:   fmt.Sprintf("⏳ Interruption will be sent in %d seconds", int(timeUntilInterruption.Seconds())),
+				NextEvent: timeUntilInterruption,
+				Timestamp: time.Now(),
+			}
+			_, wait := startSpan(ctx, "waitForInterruption")
+			err := sleep(ctx, timeUntilInterruption)
+			endSpan(wait, err)
+			if err != nil {
+				return err
+			}
 		}
-		time.Sleep(timeUntilStart)
 	}
-	ticker := time.NewTicker(5 * time.Second)
+	endTime, err := i.pollExperiment(ctx, events, experiment)
+	if err != nil {
+		return err
+	}
+	// the instances shut down 2 minutes after the notification, which may have been a while ago when attaching to an
+	// existing experiment
+	shutdown := lo.FromPtrOr(endTime, time.Now()).Add(2 * time.Minute)
+	events <- Event{
+		Timestamp: time.Now(),
+		Type:      EventInterruptionNotification,
+		Message:   "✅ Spot 2-minute Interruption Notification sent",
+		NextEvent: max(time.Until(shutdown), 0),
+	}
+	_, wait := startSpan(ctx, "waitForShutdown")
+	err = sleep(ctx, time.Until(shutdown))
+	endSpan(wait, err)
+	if err != nil {
+		return err
+	}
+	events <- Event{
+		Timestamp: time.Now(),
+		Type:      EventShutdown,
+		Message:   "✅ Spot Instance Shutdown sent",
+	}
+	return nil
+}
+
+// sleep waits for the duration, returning early if the context is done so that a monitor that's been detached from
+// doesn't outlive it
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pollExperiment reports the state of the experiment until it completes, returning when it ended
+func (i ITN) pollExperiment(ctx context.Context, events chan Event, experiment *types.Experiment) (endTime *time.Time, err error) {
+	ctx, span := startSpan(ctx, "pollExperiment")
+	defer func() { endSpan(span, err) }()
+	ticker := time.NewTicker(lo.CoalesceOrEmpty(i.pollInterval, experimentPollInterval))
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
 			experimentUpdate, err := i.fisClient.GetExperiment(ctx, &fis.GetExperimentInput{Id: experiment.Id})
 			if err != nil {
-				return err
+				return nil, err
 			}
 			switch experimentUpdate.Experiment.State.Status {
 			case types.ExperimentStatusPending:
 				events <- Event{
 					Timestamp: time.Now(),
+					Type:      EventExperimentPending,
 					Message:   "⏰ Interruption Experiment is pending",
 				}
 			case types.ExperimentStatusInitiating:
 				events <- Event{
 					Timestamp: time.Now(),
+					Type:      EventExperimentInitiating,
 					Message:   "🔧 Interruption Experiment is initializing",
 				}
 			case types.ExperimentStatusFailed, types.ExperimentStatusStopped:
-				return errors.New(*experimentUpdate.Experiment.State.Reason)
+				return nil, errors.New(*experimentUpdate.Experiment.State.Reason)
 			case types.ExperimentStatusCompleted:
-				events <- Event{
-					Timestamp: time.Now(),
-					Message:   "✅ Spot 2-minute Interruption Notification sent",
-					NextEvent: time.Minute * 2,
-				}
-				time.Sleep(2 * time.Minute)
-				events <- Event{
-					Timestamp: time.Now(),
-					Message:   "✅ Spot Instance Shutdown sent",
-				}
-				return nil
+				return experimentUpdate.Experiment.EndTime, nil
 			}
 		case <-ctx.Done():
-			return fmt.Errorf("timed out")
+			return nil, fmt.Errorf("timed out")
 		}
 	}
 }
 
-func (i ITN) createInterruptions(ctx context.Context, instanceIDs []string, delay time.Duration) (*types.Experiment, error) {
-	accountID, err := i.getAccountID(ctx)
+func (i ITN) createInterruptions(ctx context.Context, instanceIDs []string, opts Options) (*types.Experiment, error) {
+	identity, err := i.stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
 		return nil, err
 	}
-	roleARN, err := i.getOrCreateFISRole(ctx, accountID)
+	accountID := *identity.Account
+	// record who started the experiment, since FIS doesn't
+	tags := map[string]string{createdByTag: aws.ToString(identity.Arn)}
+	roleARN := aws.String(opts.RoleARN)
+	if opts.RoleARN == "" {
+		if roleARN, err = i.getOrCreateFISRole(ctx, accountID); err != nil {
+			return nil, err
+		}
+	}
+	var templateID *string
+	if opts.Mode == ModePersistent {
+		tags[templateNameTag] = opts.TemplateName
+		if templateID, err = i.putPersistentTemplate(ctx, instanceIDs, opts, roleARN, tags); err != nil {
+			return nil, err
+		}
+	} else if templateID, err = i.createTemplate(ctx, instanceIDs, accountID, opts, roleARN, tags); err != nil {
+		return nil, err
+	}
+	ctx, span := startSpan(ctx, "StartExperiment")
+	experiment, err := i.fisClient.StartExperiment(ctx, &fis.StartExperimentInput{ExperimentTemplateId: templateID, Tags: tags})
 	if err != nil {
+		endSpan(span, err)
 		return nil, err
 	}
+	span.SetAttributes(ExperimentIDAttribute.String(aws.ToString(experiment.Experiment.Id)))
+	endSpan(span, nil)
+	return experiment.Experiment, nil
+}
+
+// createTemplate creates an experiment template targeting the instances by ARN for a single interruption
+func (i ITN) createTemplate(ctx context.Context, instanceIDs []string, accountID string, opts Options, roleARN *string, tags map[string]string) (*string, error) {
+	actions, targets := i.templateTargets(instanceIDs, accountID, opts.Delay)
 	template := &fis.CreateExperimentTemplateInput{
-		Actions:        map[string]types.CreateExperimentTemplateActionInput{},
-		Targets:        map[string]types.CreateExperimentTemplateTargetInput{},
-		StopConditions: []types.CreateExperimentTemplateStopConditionInput{{Source: aws.String("none")}},
+		Actions:        actions,
+		Targets:        targets,
+		StopConditions: stopConditions(opts.StopAlarms),
 		RoleArn:        roleARN,
+		Tags:           tags,
 		Description:    aws.String(fmt.Sprintf("trigger spot ITN for instances %v", instanceIDs)),
 	}
+	ctx, span := startSpan(ctx, "CreateExperimentTemplate", InstanceIDsAttribute.StringSlice(instanceIDs))
+	experimentTemplate, err := i.fisClient.CreateExperimentTemplate(ctx, template)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return experimentTemplate.ExperimentTemplate.Id, nil
+}
+
+// templateTargets are the actions and targets of a template interrupting the instances by ARN, in batches of
+// fisTargetLimit since that's how many instances an action can target
+func (i ITN) templateTargets(instanceIDs []string, accountID string, delay time.Duration) (map[string]types.CreateExperimentTemplateActionInput, map[string]types.CreateExperimentTemplateTargetInput) {
+	actions := map[string]types.CreateExperimentTemplateActionInput{}
+	targets := map[string]types.CreateExperimentTemplateTargetInput{}
 	for j, batch := range i.batchInstances(instanceIDs, fisTargetLimit) {
 		key := fmt.Sprintf("itn%d", j)
-		template.Actions[key] = types.CreateExperimentTemplateActionInput{
-			ActionId: ptr.String(SpotITNAction),
-			Parameters: map[string]string{
-				// durationBeforeInterruption is the time before the instance is terminated, so we add 2 minutes
-				// so that a user can configure the notificatin delay rather than the termination delay.
-				"durationBeforeInterruption": fmt.Sprintf("PT%dS", int((time.Minute*2 + delay).Seconds())),
-			},
-			Targets: map[string]string{"SpotInstances": key},
+		actions[key] = types.CreateExperimentTemplateActionInput{
+			ActionId:   ptr.String(SpotITNAction),
+			Parameters: actionParameters(delay),
+			Targets:    map[string]string{"SpotInstances": key},
 		}
-		template.Targets[key] = types.CreateExperimentTemplateTargetInput{
+		targets[key] = types.CreateExperimentTemplateTargetInput{
 			ResourceType:  ptr.String("aws:ec2:spot-instance"),
 			SelectionMode: ptr.String("ALL"),
 			ResourceArns:  i.instanceIDsToARNs(batch, i.cfg.Region, accountID),
 		}
 	}
-	experimentTemplate, err := i.fisClient.CreateExperimentTemplate(ctx, template)
-	if err != nil {
-		return nil, err
+	return actions, targets
+}
+
+func actionParameters(delay time.Duration) map[string]string {
+	return map[string]string{
+		// durationBeforeInterruption is the time before the instance is terminated, so we add 2 minutes
+		// so that a user can configure the notificatin delay rather than the termination delay.
+		"durationBeforeInterruption": fmt.Sprintf("PT%dS", int((time.Minute*2 + delay).Seconds())),
+	}
+}
+
+// ExperimentDelay infers the delay an experiment was started with from the durationBeforeInterruption parameter of
+// its actions, which is the delay plus the 2 minutes of the interruption notification
+func ExperimentDelay(experiment *types.Experiment) (time.Duration, error) {
+	for _, action := range experiment.Actions {
+		if lo.FromPtr(action.ActionId) != SpotITNAction {
+			continue
+		}
+		if duration, ok := action.Parameters["durationBeforeInterruption"]; ok {
+			durationBeforeInterruption, err := parseISO8601Duration(duration)
+			if err != nil {
+				return 0, err
+			}
+			return max(durationBeforeInterruption-2*time.Minute, 0), nil
+		}
+	}
+	return 0, fmt.Errorf("experiment %s has no %s action with a durationBeforeInterruption", lo.FromPtr(experiment.Id), SpotITNAction)
+}
+
+// parseISO8601Duration parses the time part of an ISO 8601 duration, like PT2M15S
+func parseISO8601Duration(duration string) (time.Duration, error) {
+	remaining, ok := strings.CutPrefix(duration, "PT")
+	if !ok || remaining == "" {
+		return 0, fmt.Errorf("invalid duration %q", duration)
 	}
-	experiment, err := i.fisClient.StartExperiment(ctx, &fis.StartExperimentInput{ExperimentTemplateId: experimentTemplate.ExperimentTemplate.Id})
+	// time.ParseDuration accepts the same units in lower case, like 2m15s
+	parsed, err := time.ParseDuration(strings.ToLower(remaining))
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("invalid duration %q", duration)
 	}
-	return experiment.Experiment, nil
+	return parsed, nil
+}
+
+// stopConditions stops an experiment when any of the alarms is in alarm, or never if there are no alarms
+func stopConditions(alarmARNs []string) []types.CreateExperimentTemplateStopConditionInput {
+	if len(alarmARNs) == 0 {
+		return []types.CreateExperimentTemplateStopConditionInput{{Source: aws.String("none")}}
+	}
+	var conditions []types.CreateExperimentTemplateStopConditionInput
+	for _, alarmARN := range alarmARNs {
+		conditions = append(conditions, types.CreateExperimentTemplateStopConditionInput{
+			Source: aws.String("aws:cloudwatch:alarm"),
+			Value:  aws.String(alarmARN),
+		})
+	}
+	return conditions
 }
 
 func (i ITN) batchInstances(instanceIDs []string, size int) [][]string {
@@ -295,7 +626,9 @@ func (i ITN) batchInstances(instanceIDs []string, size int) [][]string {
 	return instanceIDBatches
 }
 
-func (i ITN) getOrCreateFISRole(ctx context.Context, accountID string) (*string, error) {
+func (i ITN) getOrCreateFISRole(ctx context.Context, accountID string) (_ *string, err error) {
+	ctx, span := startSpan(ctx, "getOrCreateFISRole")
+	defer func() { endSpan(span, err) }()
 	out, err := i.iamClient.CreateRole(ctx, &iam.CreateRoleInput{
 		RoleName:                 ptr.String(fisRoleName),
 		AssumeRolePolicyDocument: ptr.String(trustPolicy),
@@ -318,6 +651,11 @@ func (i ITN) getOrCreateFISRole(ctx context.Context, accountID string) (*string,
 	return out.Role.Arn, nil
 }
 
+// AccountID returns the ID of the AWS account the ITN's credentials belong to
+func (i ITN) AccountID(ctx context.Context) (string, error) {
+	return i.getAccountID(ctx)
+}
+
 func (i ITN) getAccountID(ctx context.Context) (string, error) {
 	identity, err := i.stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
@@ -15,18 +15,44 @@ package itn
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
 	"github.com/aws/aws-sdk-go-v2/service/fis"
 	"github.com/aws/aws-sdk-go-v2/service/fis/types"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	sqtypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 const (
@@ -34,6 +60,9 @@ const (
 	mockRegion    = "us-weast-2"
 )
 
+// testPollOptions poll quickly and give up soon, for the watchers
+var testPollOptions = PollOptions{Interval: 10 * time.Millisecond, Timeout: 100 * time.Millisecond}
+
 func TestCreateInterruptions(t *testing.T) {
 	ctx := context.Background()
 	instanceIDs := []string{"InstanceID-1"}
@@ -46,7 +75,7 @@ func TestCreateInterruptions(t *testing.T) {
 		stsClient: &stsMockClient{},
 	}
 	mockedDelay := time.Second * 5
-	output, err := itn.createInterruptions(ctx, instanceIDs, time.Duration(mockedDelay))
+	output, err := itn.createInterruptions(ctx, instanceIDs, Options{Delay: mockedDelay})
 	h.Ok(t, err)
 	h.Equals(t, fmt.Sprintf("arn:aws:iam::%s:role/%s", mockAccountID, fisRoleName), *output.RoleArn)
 	h.Equals(t, "none", *output.StopConditions[0].Source)
@@ -65,6 +94,485 @@ func TestCreateInterruptions(t *testing.T) {
 	h.Equals(t, "ALL", *actualTarget.SelectionMode)
 }
 
+func TestCreateInterruptionsWithRoleAndStopAlarms(t *testing.T) {
+	ctx := context.Background()
+	itn := ITN{
+		cfg:       aws.Config{Region: mockRegion},
+		fisClient: &fisMockClient{},
+		iamClient: &iamMockClient{},
+		stsClient: &stsMockClient{},
+	}
+	roleARN := "arn:aws:iam::12345:role/chaos"
+	alarmARNs := []string{"arn:aws:cloudwatch:us-weast-2:12345:alarm:errors", "arn:aws:cloudwatch:us-weast-2:12345:alarm:latency"}
+	output, err := itn.createInterruptions(ctx, []string{"InstanceID-1"}, Options{RoleARN: roleARN, StopAlarms: alarmARNs})
+	h.Ok(t, err)
+	h.Equals(t, roleARN, *output.RoleArn)
+	h.Equals(t, 2, len(output.StopConditions))
+	for j, stop := range output.StopConditions {
+		h.Equals(t, "aws:cloudwatch:alarm", *stop.Source)
+		h.Equals(t, alarmARNs[j], *stop.Value)
+	}
+
+	_, _, err = itn.Interrupt(ctx, []string{"InstanceID-1"}, Options{Mode: "sometimes"})
+	h.Nok(t, err)
+}
+
+func TestPersistentTemplate(t *testing.T) {
+	ctx := context.Background()
+	fisClient := &fisMockClient{}
+	ec2Client := &ec2MockClient{}
+	for _, instanceID := range []string{"i-1", "i-2"} {
+		ec2Client.launch(ec2types.Instance{InstanceId: aws.String(instanceID), Tags: []ec2types.Tag{{Key: aws.String("team"), Value: aws.String("checkout")}}})
+	}
+	itn := ITN{
+		cfg:       aws.Config{Region: mockRegion},
+		ec2Client: ec2Client,
+		fisClient: fisClient,
+		iamClient: &iamMockClient{},
+		stsClient: &stsMockClient{},
+	}
+	opts := Options{Mode: ModePersistent, Tags: map[string]string{"team": "checkout"}, TemplateName: "checkout", Delay: 5 * time.Second}
+
+	// the template is created on the first run, targeting the running instances by tags
+	experiment, err := itn.createInterruptions(ctx, []string{"i-1", "i-2"}, opts)
+	h.Ok(t, err)
+	h.Equals(t, 1, len(fisClient.templates))
+	h.Equals(t, 0, fisClient.updates)
+	h.Equals(t, []string{"itn0"}, slices.Sorted(maps.Keys(experiment.Targets)))
+	h.Equals(t, opts.Tags, experiment.Targets["itn0"].ResourceTags)
+	h.Equals(t, 0, len(ExperimentInstanceIDs(*experiment)))
+	h.Equals(t, "checkout", experiment.Tags[templateNameTag])
+
+	// reused as is while its definition doesn't change
+	_, err = itn.createInterruptions(ctx, []string{"i-1", "i-2"}, opts)
+	h.Ok(t, err)
+	h.Equals(t, 1, len(fisClient.templates))
+	h.Equals(t, 0, fisClient.updates)
+
+	// and updated when it does
+	opts.Delay = time.Minute
+	experiment, err = itn.createInterruptions(ctx, []string{"i-1", "i-2"}, opts)
+	h.Ok(t, err)
+	h.Equals(t, 1, len(fisClient.templates))
+	h.Equals(t, 1, fisClient.updates)
+	h.Equals(t, *fisClient.templates[0].Id, *experiment.ExperimentTemplateId)
+	h.Equals(t, "PT180S", experiment.Actions["itn0"].Parameters["durationBeforeInterruption"])
+	h.Equals(t, opts.Tags, experiment.Targets["itn0"].ResourceTags)
+
+	// the tags must select exactly the validated instances
+	_, err = itn.createInterruptions(ctx, []string{"i-1"}, opts)
+	h.Nok(t, err)
+	_, err = itn.createInterruptions(ctx, []string{"i-1", "i-2", "i-3"}, opts)
+	h.Nok(t, err)
+	// and no more than FIS can target
+	instanceIDs := []string{"i-1", "i-2"}
+	for j := 3; j <= fisTargetLimit+1; j++ {
+		instanceID := fmt.Sprintf("i-%d", j)
+		ec2Client.launch(ec2types.Instance{InstanceId: aws.String(instanceID), Tags: []ec2types.Tag{{Key: aws.String("team"), Value: aws.String("checkout")}}})
+		instanceIDs = append(instanceIDs, instanceID)
+	}
+	_, err = itn.createInterruptions(ctx, instanceIDs, opts)
+	h.Nok(t, err)
+	h.Equals(t, 1, fisClient.updates)
+
+	// cleaning up keeps the template
+	h.Ok(t, itn.Clean(ctx, *experiment))
+	h.Equals(t, 0, fisClient.deletes)
+
+	_, _, err = itn.Interrupt(ctx, []string{"i-1"}, Options{Mode: ModePersistent, TemplateName: "checkout"})
+	h.Nok(t, err)
+}
+
+func TestExperimentDelay(t *testing.T) {
+	experiment := func(duration string) *types.Experiment {
+		return &types.Experiment{Id: aws.String("EXP1"), Actions: map[string]types.ExperimentAction{
+			"itn0": {ActionId: aws.String(SpotITNAction), Parameters: map[string]string{"durationBeforeInterruption": duration}},
+		}}
+	}
+	delay, err := ExperimentDelay(experiment("PT125S"))
+	h.Ok(t, err)
+	h.Equals(t, 5*time.Second, delay)
+
+	delay, err = ExperimentDelay(experiment("PT3M30S"))
+	h.Ok(t, err)
+	h.Equals(t, 90*time.Second, delay)
+
+	_, err = ExperimentDelay(experiment("2 minutes"))
+	h.Nok(t, err)
+	_, err = ExperimentDelay(&types.Experiment{Id: aws.String("EXP2")})
+	h.Nok(t, err)
+}
+
+func TestAttach(t *testing.T) {
+	ctx := context.Background()
+	itn := ITN{pollInterval: 10 * time.Millisecond, fisClient: &fisMockClient{experiments: []types.Experiment{{
+		Id:        aws.String("EXP1"),
+		StartTime: aws.Time(time.Now().Add(-10 * time.Minute)),
+		EndTime:   aws.Time(time.Now().Add(-5 * time.Minute)),
+		State:     &types.ExperimentState{Status: types.ExperimentStatusCompleted},
+		Actions: map[string]types.ExperimentAction{
+			"itn0": {ActionId: aws.String(SpotITNAction), Parameters: map[string]string{"durationBeforeInterruption": "PT135S"}},
+		},
+	}}}}
+
+	experiment, events, err := itn.Attach(ctx, "EXP1")
+	h.Ok(t, err)
+	h.Equals(t, "EXP1", *experiment.Id)
+	var eventTypes []EventType
+	for event := range events {
+		eventTypes = append(eventTypes, event.Type)
+		if event.Type == EventInterruptionNotification {
+			h.Equals(t, time.Duration(0), event.NextEvent)
+		}
+	}
+	// the experiment started long ago, so nothing is scheduled and the instances have already shut down
+	h.Equals(t, []EventType{EventRebalanceRecommendation, EventInterruptionNotification, EventShutdown}, eventTypes)
+
+	_, _, err = itn.Attach(ctx, "EXP2")
+	h.Nok(t, err)
+}
+
+func TestAttachCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	itn := ITN{fisClient: &fisMockClient{experiments: []types.Experiment{{
+		Id:        aws.String("EXP1"),
+		StartTime: aws.Time(time.Now()),
+		State:     &types.ExperimentState{Status: types.ExperimentStatusRunning},
+		Actions: map[string]types.ExperimentAction{
+			"itn0": {ActionId: aws.String(SpotITNAction), Parameters: map[string]string{"durationBeforeInterruption": "PT1H"}},
+		},
+	}}}}
+
+	_, events, err := itn.Attach(ctx, "EXP1")
+	h.Ok(t, err)
+	h.Equals(t, EventRebalanceRecommendation, (<-events).Type)
+	h.Equals(t, EventInterruptionScheduled, (<-events).Type)
+	// detaching stops waiting for the interruption an hour away
+	cancel()
+	var eventTypes []EventType
+	for event := range events {
+		eventTypes = append(eventTypes, event.Type)
+	}
+	h.Equals(t, []EventType{EventError}, eventTypes)
+}
+
+func TestInterruptTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+	ctx := context.Background()
+	itn := ITN{
+		cfg:       aws.Config{Region: mockRegion},
+		ec2Client: &ec2MockClient{instances: []ec2types.Instance{spotInstance("i-1", ec2types.InstanceStateNameRunning)}},
+		fisClient: &fisMockClient{experiments: []types.Experiment{{
+			Id:      aws.String("EXP1"),
+			EndTime: aws.Time(time.Now().Add(-5 * time.Minute)),
+			State:   &types.ExperimentState{Status: types.ExperimentStatusCompleted},
+		}}},
+		iamClient:    &iamMockClient{},
+		stsClient:    &stsMockClient{},
+		pollInterval: 10 * time.Millisecond,
+	}
+
+	_, events, err := itn.Interrupt(ctx, []string{"i-1"}, Options{Clean: true})
+	h.Ok(t, err)
+	for range events {
+	}
+	spans := recorder.Ended()
+	h.Equals(t, []string{"validate", "getOrCreateFISRole", "CreateExperimentTemplate", "StartExperiment", "pollExperiment", "waitForShutdown", "monitor", "Clean", "Interrupt"},
+		lo.Map(spans, func(span sdktrace.ReadOnlySpan, _ int) string { return span.Name() }))
+	byName := lo.KeyBy(spans, func(span sdktrace.ReadOnlySpan) string { return span.Name() })
+	root := byName["Interrupt"]
+	h.Assert(t, !root.Parent().IsValid(), "expected Interrupt to be the root span")
+	h.Equals(t, []attribute.KeyValue{InstanceIDsAttribute.StringSlice([]string{"i-1"}), ExperimentIDAttribute.String("EXP1")}, root.Attributes())
+	for _, name := range []string{"validate", "getOrCreateFISRole", "CreateExperimentTemplate", "StartExperiment", "monitor", "Clean"} {
+		h.Equals(t, root.SpanContext().SpanID(), byName[name].Parent().SpanID())
+		h.Equals(t, root.SpanContext().TraceID(), byName[name].SpanContext().TraceID())
+	}
+	for _, name := range []string{"pollExperiment", "waitForShutdown"} {
+		h.Equals(t, byName["monitor"].SpanContext().SpanID(), byName[name].Parent().SpanID())
+	}
+
+	// failing to start the interruption fails its trace
+	_, _, err = itn.Interrupt(ctx, []string{"i-typo"}, Options{})
+	h.Nok(t, err)
+	spans = recorder.Ended()
+	failed := spans[len(spans)-1]
+	h.Equals(t, "Interrupt", failed.Name())
+	h.Equals(t, codes.Error, failed.Status().Code)
+}
+
+func TestExperiments(t *testing.T) {
+	ctx := context.Background()
+	experiment := func(experimentID string, templateID string, actionID string, status types.ExperimentStatus, created time.Time, tags map[string]string) types.Experiment {
+		return types.Experiment{
+			Id:                   aws.String(experimentID),
+			ExperimentTemplateId: aws.String(templateID),
+			CreationTime:         aws.Time(created),
+			State:                &types.ExperimentState{Status: status},
+			Tags:                 tags,
+			Actions:              map[string]types.ExperimentAction{"itn0": {ActionId: aws.String(actionID)}},
+			Targets: map[string]types.ExperimentTarget{
+				"itn0": {ResourceArns: []string{fmt.Sprintf("arn:aws:ec2:%s:%s:instance/i-%s", mockRegion, mockAccountID, experimentID)}},
+			},
+		}
+	}
+	now := time.Now()
+	fisClient := &fisMockClient{
+		experiments: []types.Experiment{
+			// TPL1 has been cleaned up, but the experiment is tagged with who started it
+			experiment("EXP1", "TPL1", SpotITNAction, types.ExperimentStatusCompleted, now.Add(-time.Hour), map[string]string{createdByTag: "arn:aws:iam::12345:user/alice"}),
+			experiment("EXP2", "TPL2", SpotITNAction, types.ExperimentStatusRunning, now, nil),
+			experiment("EXP3", "TPL3", "aws:ec2:stop-instances", types.ExperimentStatusRunning, now, nil),
+			experiment("EXP4", "TPL4", SpotITNAction, types.ExperimentStatusRunning, now.Add(-time.Minute), nil),
+		},
+		templates: []types.ExperimentTemplateSummary{
+			{Id: aws.String("TPL2"), Tags: map[string]string{createdByTag: "arn:aws:iam::12345:user/bob"}},
+			{Id: aws.String("TPL3")},
+			{Id: aws.String("TPL4")},
+		},
+	}
+	itn := ITN{fisClient: fisClient}
+
+	listings, err := itn.Experiments(ctx, "")
+	h.Ok(t, err)
+	h.Equals(t, []string{"EXP2", "EXP4", "EXP1"}, lo.Map(listings, func(listing Listing, _ int) string { return *listing.Experiment.Id }))
+	h.Equals(t, "arn:aws:iam::12345:user/bob", listings[0].StartedBy)
+	h.Equals(t, "", listings[1].StartedBy)
+	h.Equals(t, "arn:aws:iam::12345:user/alice", listings[2].StartedBy)
+	h.Equals(t, []string{"i-EXP1"}, listings[2].InstanceIDs())
+	// the experiment of the template that doesn't send Spot ITNs isn't described
+	h.Equals(t, 3, fisClient.described)
+
+	listings, err = itn.Experiments(ctx, "running")
+	h.Ok(t, err)
+	h.Equals(t, []string{"EXP2", "EXP4"}, lo.Map(listings, func(listing Listing, _ int) string { return *listing.Experiment.Id }))
+	h.Equals(t, 5, fisClient.described)
+}
+
+func TestLeftoverTemplates(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	created := map[string]string{createdByTag: "arn:aws:iam::12345:user/alice"}
+	fisClient := &fisMockClient{
+		experiments: []types.Experiment{
+			{Id: aws.String("EXP1"), ExperimentTemplateId: aws.String("TPL-running"), State: &types.ExperimentState{Status: types.ExperimentStatusRunning}},
+			{Id: aws.String("EXP2"), ExperimentTemplateId: aws.String("TPL-old"), State: &types.ExperimentState{Status: types.ExperimentStatusCompleted}},
+		},
+		templates: []types.ExperimentTemplateSummary{
+			{Id: aws.String("TPL-running"), Tags: created},
+			{Id: aws.String("TPL-old"), Tags: created, CreationTime: aws.Time(now.Add(-time.Hour))},
+			{Id: aws.String("TPL-newer"), Tags: created, CreationTime: aws.Time(now)},
+			{Id: aws.String("TPL-persistent"), Tags: map[string]string{createdByTag: "alice", templateNameTag: "checkout"}},
+			{Id: aws.String("TPL-someone-elses")},
+		},
+	}
+	itn := ITN{fisClient: fisClient}
+	templates, err := itn.LeftoverTemplates(ctx)
+	h.Ok(t, err)
+	h.Equals(t, []string{"TPL-newer", "TPL-old"}, lo.Map(templates, func(template types.ExperimentTemplateSummary, _ int) string { return *template.Id }))
+
+	h.Ok(t, itn.DeleteTemplate(ctx, "TPL-old"))
+	h.Equals(t, 1, fisClient.deletes)
+	h.Ok(t, itn.Stop(ctx, "EXP1"))
+	h.Equals(t, []string{"EXP1"}, fisClient.stopped)
+}
+
+func TestEventBridgeWatcher(t *testing.T) {
+	ctx := context.Background()
+	eventBridgeClient := &eventBridgeMockClient{}
+	stsClient := &stsMockClient{}
+	itn := ITN{cfg: aws.Config{Region: mockRegion}, stsClient: stsClient, eventBridgeClient: eventBridgeClient}
+	start := time.Now()
+	runs := []Run{{Region: mockRegion, Experiment: &types.Experiment{
+		Id:        aws.String("EXP1"),
+		StartTime: aws.Time(start),
+		Targets: map[string]types.ExperimentTarget{
+			"itn0": {ResourceArns: []string{fmt.Sprintf("arn:aws:ec2:%s:%s:instance/i-1", mockRegion, mockAccountID)}},
+		},
+	}}}
+	events := make(chan Event, 10)
+	for _, event := range []Event{
+		{Type: EventRebalanceRecommendation, Timestamp: start},
+		{Type: EventExperimentPending, Timestamp: start.Add(5 * time.Second)},
+		{Type: EventExperimentPending, Timestamp: start.Add(10 * time.Second)},
+		{Type: EventRecoveryReport, Timestamp: start.Add(10 * time.Second)},
+		{Type: EventInterruptionNotification, Timestamp: start.Add(15 * time.Second), NextEvent: 2 * time.Minute},
+		{Type: EventShutdown, Timestamp: start.Add(135 * time.Second)},
+	} {
+		events <- event
+	}
+	close(events)
+	var passed []EventType
+	for event := range Watch(runs, events, itn.EventBridgeWatcher(ctx, "chaos")) {
+		passed = append(passed, event.Type)
+	}
+	h.Equals(t, []EventType{EventRebalanceRecommendation, EventExperimentPending, EventExperimentPending, EventRecoveryReport, EventInterruptionNotification, EventShutdown}, passed)
+
+	// the repeated pending and the report aren't published
+	h.Equals(t, 4, len(eventBridgeClient.entries))
+	// the account is only looked up once
+	h.Equals(t, int32(1), stsClient.calls.Load())
+	entry := eventBridgeClient.entries[2]
+	h.Equals(t, "chaos", *entry.EventBusName)
+	h.Equals(t, EventSource, *entry.Source)
+	h.Equals(t, []string{fmt.Sprintf("arn:aws:ec2:%s:%s:instance/i-1", mockRegion, mockAccountID)}, entry.Resources)
+	var transition Transition
+	h.Ok(t, json.Unmarshal([]byte(*entry.Detail), &transition))
+	h.Equals(t, EventInterruptionNotification, transition.Transition)
+	h.Equals(t, "EXP1", transition.ExperimentID)
+	h.Equals(t, []string{"i-1"}, transition.InstanceIDs)
+	h.Equals(t, mockAccountID, transition.AccountID)
+	h.Equals(t, mockRegion, transition.Region)
+	h.Equals(t, 15.0, transition.ElapsedSeconds)
+	h.Equals(t, 120.0, transition.NextEventSeconds)
+
+	// failures are reported without failing the run
+	eventBridgeClient.fail = true
+	events = make(chan Event, 1)
+	events <- Event{Type: EventShutdown, Timestamp: start}
+	close(events)
+	passed = nil
+	for event := range Watch(runs, events, itn.EventBridgeWatcher(ctx, "chaos")) {
+		passed = append(passed, event.Type)
+	}
+	h.Equals(t, []EventType{EventShutdown, EventPublishFailed}, passed)
+}
+
+func TestPoller(t *testing.T) {
+	ctx := context.Background()
+	poller := NewPoller(testPollOptions)
+	var mu sync.Mutex
+	polls := 0
+	var failures []error
+	// polls until it's done
+	poller.Go(ctx, func() (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		return polls == 3, nil
+	}, func(err error) { t.Fatalf("unexpected error %v", err) })
+	// stops at the first error
+	poller.Go(ctx, func() (bool, error) { return false, errors.New("throttled") }, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures = append(failures, err)
+	})
+	// and is stopped by the timeout
+	poller.Go(ctx, func() (bool, error) { return false, nil }, func(err error) { t.Fatalf("unexpected error %v", err) })
+	start := time.Now()
+	poller.Wait()
+	h.Assert(t, time.Since(start) >= testPollOptions.Timeout, "expected to wait for the timeout")
+	h.Equals(t, 3, polls)
+	h.Equals(t, []error{errors.New("throttled")}, failures)
+
+	h.Equals(t, "drained after 42s", Milestone("drained", start, start.Add(42*time.Second+100*time.Millisecond)))
+	h.Equals(t, "not drained", Milestone("drained", start, time.Time{}))
+	h.Equals(t, PollOptions{Interval: time.Second, Timeout: time.Hour}, PollOptions{Interval: time.Second}.WithDefaults(time.Minute, time.Hour))
+}
+
+func TestContainerInstances(t *testing.T) {
+	ctx := context.Background()
+	itn := ITN{ecsClient: newECSMockClient()}
+
+	instances, err := itn.ContainerInstances(ctx, "prod", "spot")
+	h.Ok(t, err)
+	h.Equals(t, []ContainerInstance{{ARN: "ci-a", InstanceID: "i-0a"}}, instances)
+
+	instances, err = itn.ContainerInstances(ctx, "prod", "")
+	h.Ok(t, err)
+	h.Equals(t, 3, len(instances))
+	h.Assert(t, !lo.ContainsBy(instances, func(instance ContainerInstance) bool { return instance.ARN == "ci-x" }), "expected the external container instance to be skipped, got %v", instances)
+
+	_, err = itn.ContainerInstances(ctx, "prod", "gpu")
+	h.Nok(t, err)
+}
+
+func TestECSDrainWatcher(t *testing.T) {
+	ctx := context.Background()
+	itn := ITN{ecsClient: newECSMockClient()}
+	events := make(chan Event)
+	// ci-c is selected but left out of the run, like an instance skipped by validation, so it isn't expected to drain
+	instances := []ContainerInstance{{ARN: "ci-a", InstanceID: "i-0a"}, {ARN: "ci-b", InstanceID: "i-0b"}, {ARN: "ci-c", InstanceID: "i-0c"}}
+	runs := []Run{{InstanceIDs: []string{"i-0a", "i-0b"}}}
+	out := itn.ECSDrainWatcher(ctx, "prod", instances, testPollOptions)(runs, events)
+
+	// ci-a and ci-b are both DRAINING, and the web tasks started on ci-b since the notification don't replace those
+	// of ci-a since it's interrupted too, while the two on ci-c only replace those of one of them
+	events <- Event{Type: EventInterruptionNotification, Timestamp: time.Now().Add(-time.Minute)}
+	var messages []string
+	for event := range out {
+		messages = append(messages, event.Message)
+		if event.Type == EventTasksRescheduled {
+			break
+		}
+	}
+	h.Assert(t, lo.ContainsBy(messages, func(message string) bool {
+		return strings.HasPrefix(message, "🔁 2 tasks of service web rescheduled off container instance i-0a")
+	}), "expected the web tasks to be rescheduled, got %v", messages)
+
+	events <- Event{Type: EventShutdown, Timestamp: time.Now()}
+	close(events)
+	var errs, reports []string
+	for event := range out {
+		switch event.Type {
+		case EventError:
+			errs = append(errs, event.Message)
+		case EventECSDrainReport:
+			reports = append(reports, event.Message)
+		}
+	}
+	h.Equals(t, []string{"❌ Tasks of container instance i-0b weren't rescheduled"}, errs)
+	h.Equals(t, 2, len(reports))
+	h.Assert(t, strings.HasPrefix(reports[0], "📋 Container instance i-0a (services web): DRAINING after"), "unexpected report %q", reports[0])
+	h.Assert(t, strings.HasPrefix(reports[1], "📋 Container instance i-0b (services web): DRAINING after") && strings.HasSuffix(reports[1], "not rescheduled"), "unexpected report %q", reports[1])
+}
+
+func TestRecoveryWatcher(t *testing.T) {
+	ctx := context.Background()
+	inASG := func(instanceID string, asg string) ec2types.Instance {
+		instance := spotInstance(instanceID, ec2types.InstanceStateNameRunning)
+		instance.Tags = []ec2types.Tag{{Key: aws.String("aws:autoscaling:groupName"), Value: aws.String(asg)}}
+		return instance
+	}
+	for _, tags := range []map[string]string{nil, {"aws:autoscaling:groupName": "web"}} {
+		ec2Client := &ec2MockClient{instances: []ec2types.Instance{inASG("i-0a", "web"), inASG("i-0b", "web"), inASG("i-0z", "batch")}}
+		itn := ITN{ec2Client: ec2Client}
+		// an experiment targeting the instances by tags doesn't list them, so the run's instances are the interrupted ones
+		experiment := &types.Experiment{Targets: map[string]types.ExperimentTarget{
+			"SpotInstances": {ResourceArns: []string{"arn:aws:ec2:us-weast-2:12345:instance/i-0a"}},
+		}}
+		if tags != nil {
+			experiment = &types.Experiment{Targets: map[string]types.ExperimentTarget{"SpotInstances": {ResourceTags: tags}}}
+		}
+		runs := []Run{{Experiment: experiment, InstanceIDs: []string{"i-0a"}}}
+		events := make(chan Event)
+		out := itn.RecoveryWatcher(ctx, tags, testPollOptions)(runs, events)
+
+		events <- Event{Type: EventInterruptionNotification, Timestamp: time.Now()}
+		nextEvent := func(eventType EventType) Event {
+			for event := range out {
+				h.Assert(t, event.Type != EventCapacityRestored || eventType == EventCapacityRestored, "capacity restored before the replacement launched")
+				if event.Type == eventType {
+					return event
+				}
+			}
+			t.Fatalf("events closed before a %s event", eventType)
+			return Event{}
+		}
+		nextEvent(EventInterruptionNotification)
+		// the interrupted instance still runs until shutdown, so it doesn't count towards the restored capacity
+		time.Sleep(2 * testPollOptions.Interval)
+		ec2Client.launch(inASG("i-0c", "web"))
+		launched := nextEvent(EventReplacementLaunched)
+		h.Assert(t, strings.HasPrefix(launched.Message, "🚀 Replacement i-0c launched in aws:autoscaling:groupName=web"), "unexpected event %q", launched.Message)
+		nextEvent(EventCapacityRestored)
+		close(events)
+		report := nextEvent(EventRecoveryReport)
+		h.Equals(t, "📋 Recovery of aws:autoscaling:groupName=web: replacement launched after 0s, capacity restored to 2 Spot instances, MTTR 0s", report.Message)
+	}
+}
+
 func TestValidate(t *testing.T) {
 	// no instances
 	ctx := context.Background()
@@ -74,12 +582,70 @@ func TestValidate(t *testing.T) {
 	h.Nok(t, err)
 	h.Equals(t, "no instances specified", err.Error())
 
-	/*
-		TODO(brycahta@) after refactoring pager:
-		- no errors
-		- spot/running instances cases
-	*/
+	fisClient := &fisMockClient{
+		experiments: []types.Experiment{{
+			Id:    aws.String("EXP1"),
+			State: &types.ExperimentState{Status: types.ExperimentStatusPending},
+			Tags:  map[string]string{createdByTag: "arn:aws:iam::12345:user/alice"},
+			Targets: map[string]types.ExperimentTarget{
+				"itn0": {ResourceArns: []string{fmt.Sprintf("arn:aws:ec2:%s:%s:instance/i-busy", mockRegion, mockAccountID)}},
+			},
+		}, {
+			Id:                   aws.String("EXP2"),
+			ExperimentTemplateId: aws.String("TPL-stop"),
+			State:                &types.ExperimentState{Status: types.ExperimentStatusRunning},
+			Actions:              map[string]types.ExperimentAction{"stop": {ActionId: aws.String("aws:ec2:stop-instances")}},
+		}},
+		templates: []types.ExperimentTemplateSummary{{Id: aws.String("TPL-stop")}},
+	}
+	itn = ITN{
+		cfg: aws.Config{Region: mockRegion},
+		ec2Client: &ec2MockClient{instances: []ec2types.Instance{
+			spotInstance("i-spot", ec2types.InstanceStateNameRunning),
+			spotInstance("i-stopped", ec2types.InstanceStateNameStopped),
+			spotInstance("i-busy", ec2types.InstanceStateNameRunning),
+			{InstanceId: aws.String("i-od"), State: &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning}},
+		}},
+		fisClient: fisClient,
+	}
+	valid, err := itn.Validate(ctx, []string{"i-spot"})
+	h.Ok(t, err)
+	h.Equals(t, []string{"i-spot"}, valid)
+	// only the Spot ITN experiment is described
+	h.Equals(t, 1, fisClient.described)
+
+	valid, err = itn.Validate(ctx, []string{"i-spot", "i-stopped", "i-od", "i-busy", "i-typo"})
+	h.Equals(t, []string{"i-spot"}, valid)
+	validationErr, ok := err.(*ValidationError)
+	h.Assert(t, ok, "expected a *ValidationError but got %v", err)
+	h.Equals(t, []InvalidInstance{
+		{InstanceID: "i-typo", Region: mockRegion, Reason: ReasonNotFound},
+		{InstanceID: "i-stopped", Region: mockRegion, Reason: ReasonNotRunning, Detail: "stopped"},
+		{InstanceID: "i-od", Region: mockRegion, Reason: ReasonNotSpot},
+		{InstanceID: "i-busy", Region: mockRegion, Reason: ReasonScheduled, Detail: "EXP1"},
+	}, validationErr.Invalid)
 
+	// the active experiments can't be listed
+	fisClient.listErr = errors.New("not authorized to perform fis:ListExperiments")
+	_, err = itn.Validate(ctx, []string{"i-spot"})
+	h.Equals(t, "listing the active experiments: not authorized to perform fis:ListExperiments", err.Error())
+
+	// instance in another region
+	multiRegion := MultiRegion{itns: map[string]*ITN{
+		"us-east-1": {cfg: aws.Config{Region: "us-east-1"}, ec2Client: &ec2MockClient{}, fisClient: &fisMockClient{}},
+		"us-west-2": {cfg: aws.Config{Region: "us-west-2"}, ec2Client: &ec2MockClient{instances: []ec2types.Instance{spotInstance("i-west", ec2types.InstanceStateNameRunning)}}, fisClient: &fisMockClient{}},
+	}}
+	validByRegion, err := multiRegion.Validate(ctx, map[string][]string{"us-east-1": {"i-west"}})
+	h.Equals(t, map[string][]string{}, validByRegion)
+	h.Equals(t, "i-west is in another region (us-west-2)", err.Error())
+}
+
+func spotInstance(instanceID string, state ec2types.InstanceStateName) ec2types.Instance {
+	return ec2types.Instance{
+		InstanceId:        aws.String(instanceID),
+		InstanceLifecycle: ec2types.InstanceLifecycleTypeSpot,
+		State:             &ec2types.InstanceState{Name: state},
+	}
 }
 
 func TestGetOrCreateFISRole(t *testing.T) {
@@ -188,44 +754,403 @@ func TestBatchInstances(t *testing.T) {
 	h.Equals(t, expectedBatches, out)
 }
 
+func TestCheckGuardrails(t *testing.T) {
+	ctx := context.Background()
+	asgInstance := func(instanceID string, tags ...ec2types.Tag) ec2types.Instance {
+		instance := spotInstance(instanceID, ec2types.InstanceStateNameRunning)
+		instance.Tags = append(tags, ec2types.Tag{Key: aws.String(asgNameTag), Value: aws.String("web")})
+		return instance
+	}
+	itn := ITN{
+		stsClient: &stsMockClient{},
+		ec2Client: &ec2MockClient{instances: []ec2types.Instance{
+			asgInstance("i-0"),
+			asgInstance("i-1"),
+			asgInstance("i-2"),
+			asgInstance("i-3", ec2types.Tag{Key: aws.String("protected"), Value: aws.String("true")}),
+		}},
+	}
+
+	h.Ok(t, itn.CheckGuardrails(ctx, []string{"i-0", "i-1"}, Guardrails{}))
+
+	err := itn.CheckGuardrails(ctx, []string{"i-0", "i-3"}, Guardrails{ProtectedTags: map[string]string{"protected": "true"}})
+	h.Assert(t, err != nil && strings.Contains(err.Error(), "i-3 is protected"), "expected i-3 to be protected, got %v", err)
+
+	h.Ok(t, itn.CheckGuardrails(ctx, []string{"i-0", "i-1"}, Guardrails{MaxASGFraction: 0.5}))
+	err = itn.CheckGuardrails(ctx, []string{"i-0", "i-1", "i-2"}, Guardrails{MaxASGFraction: 0.5})
+	h.Assert(t, err != nil && strings.Contains(err.Error(), "3 of the 4 running instances"), "expected the ASG fraction to be exceeded, got %v", err)
+
+	err = itn.CheckGuardrails(ctx, []string{"i-0", "i-1", "i-2"}, Guardrails{MaxInstances: 2})
+	h.Assert(t, err != nil && strings.Contains(err.Error(), "exceeds the maximum of 2"), "expected the maximum instances to be exceeded, got %v", err)
+
+	err = itn.CheckGuardrails(ctx, []string{"i-0"}, Guardrails{AllowedAccounts: []string{"67890"}})
+	h.Assert(t, err != nil && strings.Contains(err.Error(), "not one of the allowed accounts"), "expected the account to be rejected, got %v", err)
+	h.Ok(t, itn.CheckGuardrails(ctx, []string{"i-0"}, Guardrails{AllowedAccounts: []string{mockAccountID}}))
+}
+
+func TestParseInstanceSelectors(t *testing.T) {
+	byRegion, unqualified := ParseInstanceSelectors([]string{"us-west-2:i-1", "i-2", "eu-west-1:i-3", "us-west-2:i-4"})
+	h.Equals(t, map[string][]string{
+		"us-west-2": {"i-1", "i-4"},
+		"eu-west-1": {"i-3"},
+	}, byRegion)
+	h.Equals(t, []string{"i-2"}, unqualified)
+}
+
+func TestMerge(t *testing.T) {
+	first := make(chan Event, 2)
+	second := make(chan Event, 1)
+	first <- Event{Message: "one"}
+	first <- Event{Message: "two"}
+	second <- Event{Message: "three"}
+	close(first)
+	close(second)
+	var messages []string
+	for event := range Merge(withRegion(first, "us-east-1"), second) {
+		messages = append(messages, event.Message)
+		if event.Message != "three" {
+			h.Equals(t, "us-east-1", event.Region)
+		}
+	}
+	h.ItemsMatch(t, []string{"one", "two", "three"}, messages)
+}
+
+func TestMultiRegionInterrupt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	regionITN := func(region string, instanceID string) *ITN {
+		return &ITN{
+			cfg:          aws.Config{Region: region},
+			pollInterval: time.Millisecond,
+			ec2Client:    &ec2MockClient{instances: []ec2types.Instance{spotInstance(instanceID, ec2types.InstanceStateNameRunning)}},
+			fisClient:    &fisMockClient{},
+			iamClient:    &iamMockClient{},
+			stsClient:    &stsMockClient{},
+		}
+	}
+	multiRegion := MultiRegion{itns: map[string]*ITN{
+		"us-east-1": regionITN("us-east-1", "i-east"),
+		"us-west-2": regionITN("us-west-2", "i-west"),
+	}}
+	runs, events, err := multiRegion.Interrupt(ctx, map[string][]string{"us-east-1": {"i-east"}, "us-west-2": {"i-west"}}, Options{})
+	h.Ok(t, err)
+	h.Equals(t, 2, len(runs))
+	cancel()
+	for range events {
+	}
+	// the instances are validated once, up front
+	for region, itn := range multiRegion.itns {
+		h.Assert(t, itn.fisClient.(*fisMockClient).listed == 1, "%s listed the experiments %d times", region, itn.fisClient.(*fisMockClient).listed)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	ctx := context.Background()
+	multiRegion := MultiRegion{itns: map[string]*ITN{
+		"us-east-1": {ec2Client: &ec2MockClient{instances: []ec2types.Instance{{InstanceId: aws.String("i-1")}}}},
+		"us-west-2": {ec2Client: &ec2MockClient{instances: []ec2types.Instance{{InstanceId: aws.String("i-2")}}}},
+	}}
+	located, err := multiRegion.Locate(ctx, []string{"i-1", "i-2"})
+	h.Ok(t, err)
+	h.Equals(t, map[string][]string{"us-east-1": {"i-1"}, "us-west-2": {"i-2"}}, located)
+
+	// instance in none of the regions
+	_, err = multiRegion.Locate(ctx, []string{"i-1", "i-3"})
+	h.Nok(t, err)
+}
+
+func TestAccountsInOrganizationalUnit(t *testing.T) {
+	ctx := context.Background()
+	client := &organizationsMockClient{
+		accounts: map[string][]orgtypes.Account{
+			"ou-root": {
+				{Id: aws.String("111111111111"), State: orgtypes.AccountStateActive},
+				{Id: aws.String("222222222222"), State: orgtypes.AccountStateSuspended},
+			},
+			"ou-child": {{Id: aws.String("333333333333"), State: orgtypes.AccountStateActive}},
+		},
+		ous: map[string][]string{"ou-root": {"ou-child"}},
+	}
+	accountIDs, err := accountsInOrganizationalUnit(ctx, client, "ou-root")
+	h.Ok(t, err)
+	h.Equals(t, []string{"111111111111", "333333333333"}, accountIDs)
+}
+
+func TestEventScope(t *testing.T) {
+	h.Equals(t, "", Event{}.Scope())
+	h.Equals(t, "us-west-2", Event{Region: "us-west-2"}.Scope())
+	h.Equals(t, "111111111111/us-west-2", Event{Region: "us-west-2", AccountID: "111111111111"}.Scope())
+}
+
+func TestPreflight(t *testing.T) {
+	ctx := context.Background()
+	running := &types.ExperimentState{Status: types.ExperimentStatusRunning}
+	itn := ITN{
+		cfg: aws.Config{Region: mockRegion},
+		fisClient: &fisMockClient{experiments: []types.Experiment{{
+			Id:    aws.String("EXP1"),
+			State: running,
+			Targets: map[string]types.ExperimentTarget{
+				"itn0": {ResourceArns: []string{fmt.Sprintf("arn:aws:ec2:%s:%s:instance/i-busy", mockRegion, mockAccountID)}},
+			},
+		}}},
+		iamClient:    &iamMockClient{},
+		stsClient:    &stsMockClient{},
+		quotasClient: &quotasMockClient{},
+		ec2Client: &ec2MockClient{instances: []ec2types.Instance{
+			{InstanceId: aws.String("i-1")},
+			{InstanceId: aws.String("i-busy")},
+		}},
+	}
+	checks := itn.Preflight(ctx, []string{"i-1"})
+	h.Ok(t, checks.Err())
+	h.Equals(t, 6, len(checks))
+
+	// not found and already in an active experiment
+	checks = itn.Preflight(ctx, []string{"i-1", "i-busy", "i-typo"})
+	h.Nok(t, checks.Err())
+	h.Equals(t, "pre-flight checks failed: Instances found, Instances not in active experiments", checks.Err().Error())
+	h.Equals(t, "i-typo not found in us-weast-2", checks[4].Message)
+
+	// missing permissions on an existing role
+	deniedCtx := context.WithValue(context.WithValue(ctx, "denied", "iam:PassRole"), "roleExists", "yup")
+	checks = itn.Preflight(deniedCtx, nil)
+	h.Equals(t, CheckFailed, checks[1].Status)
+	h.Equals(t, CheckPassed, checks[2].Status)
+	h.Equals(t, "arn:aws:iam::12345:role/admin is not allowed iam:PassRole (implicitDeny)", checks[1].Message)
+
+	// the instances are still checked when the active experiments can't be listed
+	itn.fisClient.(*fisMockClient).listErr = errors.New("not authorized to perform fis:ListExperiments")
+	checks = itn.Preflight(ctx, []string{"i-typo"})
+	h.Equals(t, 6, len(checks))
+	h.Equals(t, "pre-flight checks failed: FIS experiment quota, Instances found, Instances not in active experiments", checks.Err().Error())
+}
+
+func TestInstanceStates(t *testing.T) {
+	itn := ITN{ec2Client: &ec2MockClient{instances: []ec2types.Instance{
+		spotInstance("i-1", ec2types.InstanceStateNameRunning),
+		spotInstance("i-2", ec2types.InstanceStateNameShuttingDown),
+	}}}
+	states, err := itn.InstanceStates(context.Background(), []string{"i-1", "i-2", "i-gone"})
+	h.Ok(t, err)
+	h.Equals(t, map[string]string{"i-1": "running", "i-2": "shutting-down"}, states)
+}
+
+func TestSpotInstanceRequest(t *testing.T) {
+	ctx := context.Background()
+	itn := ITN{ec2Client: &ec2MockClient{spotRequests: []ec2types.SpotInstanceRequest{
+		{SpotInstanceRequestId: aws.String("sir-1"), State: ec2types.SpotInstanceStateActive},
+	}}}
+	instance := spotInstance("i-1", ec2types.InstanceStateNameRunning)
+	request, err := itn.SpotInstanceRequest(ctx, instance)
+	h.Ok(t, err)
+	h.Assert(t, request == nil, "expected no Spot request for an instance without one")
+
+	instance.SpotInstanceRequestId = aws.String("sir-1")
+	request, err = itn.SpotInstanceRequest(ctx, instance)
+	h.Ok(t, err)
+	h.Equals(t, ec2types.SpotInstanceStateActive, request.State)
+}
+
+func TestProfile(t *testing.T) {
+	h.Equals(t, "", ITN{}.Profile())
+	h.Equals(t, "dev", ITN{cfg: aws.Config{ConfigSources: []interface{}{awsconfig.EnvConfig{}, awsconfig.SharedConfig{Profile: "dev"}}}}.Profile())
+}
+
 // Mocks
 
-type fisMockClient struct {
-	experimentTemplate fis.CreateExperimentTemplateOutput
+type organizationsMockClient struct {
+	accounts map[string][]orgtypes.Account
+	ous      map[string][]string
 }
-type iamMockClient struct{}
-type stsMockClient struct{}
 
-func (f *fisMockClient) CreateExperimentTemplate(ctx context.Context, params *fis.CreateExperimentTemplateInput, optFns ...func(*fis.Options)) (*fis.CreateExperimentTemplateOutput, error) {
-	mockedAction := types.ExperimentTemplateAction{
-		ActionId:    params.Actions["itn0"].ActionId,
-		Description: params.Description,
-		Parameters:  params.Actions["itn0"].Parameters,
-		Targets:     params.Actions["itn0"].Targets,
+func (o *organizationsMockClient) ListAccountsForParent(ctx context.Context, params *organizations.ListAccountsForParentInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsForParentOutput, error) {
+	return &organizations.ListAccountsForParentOutput{Accounts: o.accounts[*params.ParentId]}, nil
+}
+
+func (o *organizationsMockClient) ListOrganizationalUnitsForParent(ctx context.Context, params *organizations.ListOrganizationalUnitsForParentInput, optFns ...func(*organizations.Options)) (*organizations.ListOrganizationalUnitsForParentOutput, error) {
+	var ous []orgtypes.OrganizationalUnit
+	for _, id := range o.ous[*params.ParentId] {
+		ous = append(ous, orgtypes.OrganizationalUnit{Id: aws.String(id)})
+	}
+	return &organizations.ListOrganizationalUnitsForParentOutput{OrganizationalUnits: ous}, nil
+}
+
+type ec2MockClient struct {
+	instances    []ec2types.Instance
+	spotRequests []ec2types.SpotInstanceRequest
+	mu           sync.Mutex
+}
+
+func (e *ec2MockClient) DescribeSpotInstanceRequests(ctx context.Context, params *ec2.DescribeSpotInstanceRequestsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotInstanceRequestsOutput, error) {
+	requests := lo.Filter(e.spotRequests, func(request ec2types.SpotInstanceRequest, _ int) bool {
+		return h.Contains(params.SpotInstanceRequestIds, *request.SpotInstanceRequestId)
+	})
+	return &ec2.DescribeSpotInstanceRequestsOutput{SpotInstanceRequests: requests}, nil
+}
+
+func (e *ec2MockClient) launch(instance ec2types.Instance) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.instances = append(e.instances, instance)
+}
+
+func (e *ec2MockClient) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	instanceIDs := params.InstanceIds
+	tags := map[string][]string{}
+	for _, filter := range params.Filters {
+		if *filter.Name == "instance-id" {
+			instanceIDs = filter.Values
+		}
+		if key, ok := strings.CutPrefix(*filter.Name, "tag:"); ok {
+			tags[key] = filter.Values
+		}
 	}
-	mockedActions := map[string]types.ExperimentTemplateAction{
-		"itn0": mockedAction,
+	var instances []ec2types.Instance
+	for _, instance := range e.instances {
+		if len(tags) == 0 && !h.Contains(instanceIDs, *instance.InstanceId) {
+			continue
+		}
+		if len(tags) > 0 && !hasTags(instance, tags) {
+			continue
+		}
+		instances = append(instances, instance)
 	}
-	mockedStop := types.ExperimentTemplateStopCondition{
-		Source: params.StopConditions[0].Source,
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{Instances: instances}}}, nil
+}
+
+func hasTags(instance ec2types.Instance, tags map[string][]string) bool {
+	for key, values := range tags {
+		if !lo.ContainsBy(instance.Tags, func(tag ec2types.Tag) bool { return *tag.Key == key && h.Contains(values, *tag.Value) }) {
+			return false
+		}
 	}
-	mockedTarget := types.ExperimentTemplateTarget{
-		ResourceArns:  params.Targets["itn0"].ResourceArns,
-		ResourceType:  params.Targets["itn0"].ResourceType,
-		SelectionMode: params.Targets["itn0"].SelectionMode,
+	return true
+}
+
+type ecsMockClient struct {
+	instances []ecstypes.ContainerInstance
+	tasks     []ecstypes.Task
+}
+
+func newECSMockClient() *ecsMockClient {
+	task := func(arn string, instanceARN string, group string, created time.Time) ecstypes.Task {
+		return ecstypes.Task{TaskArn: aws.String(arn), ContainerInstanceArn: aws.String(instanceARN), Group: aws.String(group), LastStatus: aws.String("RUNNING"), CreatedAt: aws.Time(created)}
 	}
-	mockedTargets := map[string]types.ExperimentTemplateTarget{
-		"itn0": mockedTarget,
+	return &ecsMockClient{
+		instances: []ecstypes.ContainerInstance{
+			{ContainerInstanceArn: aws.String("ci-a"), Ec2InstanceId: aws.String("i-0a"), CapacityProviderName: aws.String("spot"), Status: aws.String("DRAINING")},
+			{ContainerInstanceArn: aws.String("ci-b"), Ec2InstanceId: aws.String("i-0b"), CapacityProviderName: aws.String("on-demand"), Status: aws.String("DRAINING")},
+			{ContainerInstanceArn: aws.String("ci-c"), Ec2InstanceId: aws.String("i-0c"), CapacityProviderName: aws.String("on-demand"), Status: aws.String("ACTIVE")},
+			// registered with ECS Anywhere, so not backed by an EC2 instance
+			{ContainerInstanceArn: aws.String("ci-x"), Status: aws.String("ACTIVE")},
+		},
+		tasks: []ecstypes.Task{
+			task("web-1", "ci-a", "service:web", time.Now().Add(-time.Hour)),
+			task("web-2", "ci-a", "service:web", time.Now().Add(-time.Hour)),
+			task("batch-1", "ci-a", "family:batch", time.Now().Add(-time.Hour)),
+			task("web-3", "ci-b", "service:web", time.Now()),
+			task("web-4", "ci-b", "service:web", time.Now()),
+			task("web-5", "ci-c", "service:web", time.Now()),
+			task("web-6", "ci-c", "service:web", time.Now()),
+		},
 	}
-	mockedID := "id-12345"
+}
+
+func (e *ecsMockClient) DescribeContainerInstances(ctx context.Context, params *ecs.DescribeContainerInstancesInput, optFns ...func(*ecs.Options)) (*ecs.DescribeContainerInstancesOutput, error) {
+	return &ecs.DescribeContainerInstancesOutput{ContainerInstances: lo.Filter(e.instances, func(instance ecstypes.ContainerInstance, _ int) bool {
+		return lo.Contains(params.ContainerInstances, *instance.ContainerInstanceArn)
+	})}, nil
+}
+
+func (e *ecsMockClient) DescribeTasks(ctx context.Context, params *ecs.DescribeTasksInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error) {
+	return &ecs.DescribeTasksOutput{Tasks: lo.Filter(e.tasks, func(task ecstypes.Task, _ int) bool {
+		return lo.Contains(params.Tasks, *task.TaskArn)
+	})}, nil
+}
+
+func (e *ecsMockClient) ListContainerInstances(ctx context.Context, params *ecs.ListContainerInstancesInput, optFns ...func(*ecs.Options)) (*ecs.ListContainerInstancesOutput, error) {
+	return &ecs.ListContainerInstancesOutput{ContainerInstanceArns: lo.Map(e.instances, func(instance ecstypes.ContainerInstance, _ int) string {
+		return *instance.ContainerInstanceArn
+	})}, nil
+}
+
+func (e *ecsMockClient) ListTasks(ctx context.Context, params *ecs.ListTasksInput, optFns ...func(*ecs.Options)) (*ecs.ListTasksOutput, error) {
+	tasks := lo.Filter(e.tasks, func(task ecstypes.Task, _ int) bool {
+		return (params.ContainerInstance == nil || *task.ContainerInstanceArn == *params.ContainerInstance) &&
+			(params.ServiceName == nil || *task.Group == "service:"+*params.ServiceName)
+	})
+	return &ecs.ListTasksOutput{TaskArns: lo.Map(tasks, func(task ecstypes.Task, _ int) string { return *task.TaskArn })}, nil
+}
+
+type fisMockClient struct {
+	experimentTemplate fis.CreateExperimentTemplateOutput
+	experiments        []types.Experiment
+	templates          []types.ExperimentTemplateSummary
+	updates            int
+	deletes            int
+	stopped            []string
+	// described is the number of experiments described
+	described int
+	// listed is the number of times the experiments were listed
+	listed  int
+	listErr error
+}
+type eventBridgeMockClient struct {
+	entries []eventbridgetypes.PutEventsRequestEntry
+	fail    bool
+}
+
+func (e *eventBridgeMockClient) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	if e.fail {
+		return &eventbridge.PutEventsOutput{FailedEntryCount: 1, Entries: []eventbridgetypes.PutEventsResultEntry{{
+			ErrorCode:    aws.String("ThrottlingException"),
+			ErrorMessage: aws.String("Rate exceeded"),
+		}}}, nil
+	}
+	e.entries = append(e.entries, params.Entries...)
+	return &eventbridge.PutEventsOutput{}, nil
+}
+
+type iamMockClient struct{}
+type quotasMockClient struct{}
+type stsMockClient struct {
+	calls atomic.Int32
+}
+
+func (f *fisMockClient) CreateExperimentTemplate(ctx context.Context, params *fis.CreateExperimentTemplateInput, optFns ...func(*fis.Options)) (*fis.CreateExperimentTemplateOutput, error) {
+	mockedActions := lo.MapValues(params.Actions, func(action types.CreateExperimentTemplateActionInput, _ string) types.ExperimentTemplateAction {
+		return types.ExperimentTemplateAction{
+			ActionId:    action.ActionId,
+			Description: params.Description,
+			Parameters:  action.Parameters,
+			Targets:     action.Targets,
+		}
+	})
+	mockedStops := lo.Map(params.StopConditions, func(stop types.CreateExperimentTemplateStopConditionInput, _ int) types.ExperimentTemplateStopCondition {
+		return types.ExperimentTemplateStopCondition{Source: stop.Source, Value: stop.Value}
+	})
+	mockedTargets := lo.MapValues(params.Targets, func(target types.CreateExperimentTemplateTargetInput, _ string) types.ExperimentTemplateTarget {
+		return types.ExperimentTemplateTarget{
+			ResourceArns:  target.ResourceArns,
+			ResourceTags:  target.ResourceTags,
+			ResourceType:  target.ResourceType,
+			SelectionMode: target.SelectionMode,
+		}
+	})
+	mockedID := fmt.Sprintf("id-%d", 12345+len(f.templates))
+	f.templates = append(f.templates, types.ExperimentTemplateSummary{Id: &mockedID, Tags: params.Tags})
 	output := fis.CreateExperimentTemplateOutput{
 		ExperimentTemplate: &types.ExperimentTemplate{
 			Actions:        mockedActions,
 			Description:    params.Description,
 			Id:             &mockedID,
 			RoleArn:        params.RoleArn,
-			StopConditions: []types.ExperimentTemplateStopCondition{mockedStop},
+			StopConditions: mockedStops,
 			Targets:        mockedTargets,
+			Tags:           params.Tags,
 		},
 	}
 	f.experimentTemplate = output
@@ -233,41 +1158,113 @@ func (f *fisMockClient) CreateExperimentTemplate(ctx context.Context, params *fi
 }
 
 func (f *fisMockClient) DeleteExperimentTemplate(ctx context.Context, params *fis.DeleteExperimentTemplateInput, optFns ...func(*fis.Options)) (*fis.DeleteExperimentTemplateOutput, error) {
+	f.deletes++
 	return nil, nil
 }
 
-func (f *fisMockClient) GetExperiment(ctx context.Context, params *fis.GetExperimentInput, optFns ...func(*fis.Options)) (*fis.GetExperimentOutput, error) {
-	return nil, nil
+func (f *fisMockClient) GetAction(ctx context.Context, params *fis.GetActionInput, optFns ...func(*fis.Options)) (*fis.GetActionOutput, error) {
+	return &fis.GetActionOutput{Action: &types.Action{Id: params.Id}}, nil
 }
 
-func (f *fisMockClient) StartExperiment(ctx context.Context, params *fis.StartExperimentInput, optFns ...func(*fis.Options)) (*fis.StartExperimentOutput, error) {
-	mockedExpTemplate := f.experimentTemplate.ExperimentTemplate
-	mockedAction := types.ExperimentAction{
-		ActionId:    mockedExpTemplate.Actions["itn0"].ActionId,
-		Description: mockedExpTemplate.Actions["itn0"].Description,
-		Parameters:  mockedExpTemplate.Actions["itn0"].Parameters,
-		Targets:     mockedExpTemplate.Actions["itn0"].Targets,
+func (f *fisMockClient) GetExperiment(ctx context.Context, params *fis.GetExperimentInput, optFns ...func(*fis.Options)) (*fis.GetExperimentOutput, error) {
+	f.described++
+	for _, experiment := range f.experiments {
+		if *experiment.Id == *params.Id {
+			return &fis.GetExperimentOutput{Experiment: &experiment}, nil
+		}
 	}
-	mockedActions := map[string]types.ExperimentAction{
-		"itn0": mockedAction,
+	return nil, &types.ResourceNotFoundException{Message: aws.String("experiment not found")}
+}
+
+func (f *fisMockClient) GetExperimentTemplate(ctx context.Context, params *fis.GetExperimentTemplateInput, optFns ...func(*fis.Options)) (*fis.GetExperimentTemplateOutput, error) {
+	if template := f.experimentTemplate.ExperimentTemplate; template != nil && *template.Id == *params.Id {
+		return &fis.GetExperimentTemplateOutput{ExperimentTemplate: template}, nil
 	}
-	mockedStop := types.ExperimentStopCondition{
-		Source: mockedExpTemplate.StopConditions[0].Source,
+	// the other templates have the actions of the experiments started from them
+	template := &types.ExperimentTemplate{Id: params.Id, Actions: map[string]types.ExperimentTemplateAction{}}
+	for _, experiment := range f.experiments {
+		if lo.FromPtr(experiment.ExperimentTemplateId) == *params.Id {
+			for name, action := range experiment.Actions {
+				template.Actions[name] = types.ExperimentTemplateAction{ActionId: action.ActionId}
+			}
+		}
 	}
-	mockedTarget := types.ExperimentTarget{
-		ResourceArns:  mockedExpTemplate.Targets["itn0"].ResourceArns,
-		ResourceType:  mockedExpTemplate.Targets["itn0"].ResourceType,
-		SelectionMode: mockedExpTemplate.Targets["itn0"].SelectionMode,
+	return &fis.GetExperimentTemplateOutput{ExperimentTemplate: template}, nil
+}
+
+func (f *fisMockClient) ListExperimentTemplates(ctx context.Context, params *fis.ListExperimentTemplatesInput, optFns ...func(*fis.Options)) (*fis.ListExperimentTemplatesOutput, error) {
+	return &fis.ListExperimentTemplatesOutput{ExperimentTemplates: f.templates}, nil
+}
+
+func (f *fisMockClient) ListExperiments(ctx context.Context, params *fis.ListExperimentsInput, optFns ...func(*fis.Options)) (*fis.ListExperimentsOutput, error) {
+	f.listed++
+	if f.listErr != nil {
+		return nil, f.listErr
 	}
-	mockedTargets := map[string]types.ExperimentTarget{
-		"itn0": mockedTarget,
+	var summaries []types.ExperimentSummary
+	for _, experiment := range f.experiments {
+		summaries = append(summaries, types.ExperimentSummary{
+			Id:                   experiment.Id,
+			ExperimentTemplateId: experiment.ExperimentTemplateId,
+			State:                experiment.State,
+			Tags:                 experiment.Tags,
+		})
 	}
+	return &fis.ListExperimentsOutput{Experiments: summaries}, nil
+}
+
+func (f *fisMockClient) UpdateExperimentTemplate(ctx context.Context, params *fis.UpdateExperimentTemplateInput, optFns ...func(*fis.Options)) (*fis.UpdateExperimentTemplateOutput, error) {
+	f.updates++
+	template := f.experimentTemplate.ExperimentTemplate
+	template.Description = params.Description
+	template.Actions = lo.MapValues(params.Actions, func(action types.UpdateExperimentTemplateActionInputItem, _ string) types.ExperimentTemplateAction {
+		return types.ExperimentTemplateAction{ActionId: action.ActionId, Parameters: action.Parameters, Targets: action.Targets}
+	})
+	template.Targets = lo.MapValues(params.Targets, func(target types.UpdateExperimentTemplateTargetInput, _ string) types.ExperimentTemplateTarget {
+		return types.ExperimentTemplateTarget{ResourceArns: target.ResourceArns, ResourceTags: target.ResourceTags, ResourceType: target.ResourceType, SelectionMode: target.SelectionMode}
+	})
+	template.StopConditions = lo.Map(params.StopConditions, func(stop types.UpdateExperimentTemplateStopConditionInput, _ int) types.ExperimentTemplateStopCondition {
+		return types.ExperimentTemplateStopCondition{Source: stop.Source, Value: stop.Value}
+	})
+	template.RoleArn = params.RoleArn
+	return &fis.UpdateExperimentTemplateOutput{ExperimentTemplate: template}, nil
+}
+
+func (f *fisMockClient) StopExperiment(ctx context.Context, params *fis.StopExperimentInput, optFns ...func(*fis.Options)) (*fis.StopExperimentOutput, error) {
+	f.stopped = append(f.stopped, *params.Id)
+	return &fis.StopExperimentOutput{}, nil
+}
+
+func (f *fisMockClient) StartExperiment(ctx context.Context, params *fis.StartExperimentInput, optFns ...func(*fis.Options)) (*fis.StartExperimentOutput, error) {
+	mockedExpTemplate := f.experimentTemplate.ExperimentTemplate
+	mockedActions := lo.MapValues(mockedExpTemplate.Actions, func(action types.ExperimentTemplateAction, _ string) types.ExperimentAction {
+		return types.ExperimentAction{
+			ActionId:    action.ActionId,
+			Description: action.Description,
+			Parameters:  action.Parameters,
+			Targets:     action.Targets,
+		}
+	})
+	mockedStops := lo.Map(mockedExpTemplate.StopConditions, func(stop types.ExperimentTemplateStopCondition, _ int) types.ExperimentStopCondition {
+		return types.ExperimentStopCondition{Source: stop.Source, Value: stop.Value}
+	})
+	mockedTargets := lo.MapValues(mockedExpTemplate.Targets, func(target types.ExperimentTemplateTarget, _ string) types.ExperimentTarget {
+		return types.ExperimentTarget{
+			ResourceArns:  target.ResourceArns,
+			ResourceTags:  target.ResourceTags,
+			ResourceType:  target.ResourceType,
+			SelectionMode: target.SelectionMode,
+		}
+	})
 	output := fis.StartExperimentOutput{
 		Experiment: &types.Experiment{
-			Actions:        mockedActions,
-			RoleArn:        mockedExpTemplate.RoleArn,
-			StopConditions: []types.ExperimentStopCondition{mockedStop},
-			Targets:        mockedTargets,
+			Id:                   aws.String("EXP1"),
+			ExperimentTemplateId: params.ExperimentTemplateId,
+			Tags:                 params.Tags,
+			Actions:              mockedActions,
+			RoleArn:              mockedExpTemplate.RoleArn,
+			StopConditions:       mockedStops,
+			Targets:              mockedTargets,
 		},
 	}
 	return &output, nil
@@ -290,14 +1287,50 @@ func (i *iamMockClient) CreateRole(ctx context.Context, params *iam.CreateRoleIn
 	return &out, nil
 }
 
+func (i *iamMockClient) GetRole(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+	if *params.RoleName == fisRoleName && ctx.Value("roleExists") == nil {
+		return nil, &iamtypes.NoSuchEntityException{}
+	}
+	return &iam.GetRoleOutput{Role: &iamtypes.Role{
+		Arn:                      aws.String(fmt.Sprintf("arn:aws:iam::%s:role/%s", mockAccountID, *params.RoleName)),
+		RoleName:                 params.RoleName,
+		AssumeRolePolicyDocument: aws.String(url.QueryEscape(trustPolicy)),
+	}}, nil
+}
+
+func (i *iamMockClient) GetRolePolicy(ctx context.Context, params *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error) {
+	return &iam.GetRolePolicyOutput{PolicyDocument: aws.String(url.QueryEscape(rolePolicy))}, nil
+}
+
+func (i *iamMockClient) SimulatePrincipalPolicy(ctx context.Context, params *iam.SimulatePrincipalPolicyInput, optFns ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	var results []iamtypes.EvaluationResult
+	for _, action := range params.ActionNames {
+		decision := iamtypes.PolicyEvaluationDecisionTypeAllowed
+		if ctx.Value("denied") == action {
+			decision = iamtypes.PolicyEvaluationDecisionTypeImplicitDeny
+		}
+		results = append(results, iamtypes.EvaluationResult{EvalActionName: aws.String(action), EvalDecision: decision})
+	}
+	return &iam.SimulatePrincipalPolicyOutput{EvaluationResults: results}, nil
+}
+
 func (i *iamMockClient) PutRolePolicy(ctx context.Context, params *iam.PutRolePolicyInput, optFns ...func(*iam.Options)) (*iam.PutRolePolicyOutput, error) {
 	return nil, nil
 }
 
 func (s *stsMockClient) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	s.calls.Add(1)
 	mockAcct := mockAccountID
 	out := sts.GetCallerIdentityOutput{
 		Account: &mockAcct,
+		Arn:     aws.String(fmt.Sprintf("arn:aws:sts::%s:assumed-role/admin/session", mockAccountID)),
 	}
 	return &out, nil
 }
+
+func (q *quotasMockClient) ListServiceQuotas(ctx context.Context, params *servicequotas.ListServiceQuotasInput, optFns ...func(*servicequotas.Options)) (*servicequotas.ListServiceQuotasOutput, error) {
+	return &servicequotas.ListServiceQuotasOutput{Quotas: []sqtypes.ServiceQuota{
+		{QuotaName: aws.String("Experiment templates"), Value: aws.Float64(500)},
+		{QuotaName: aws.String("Active experiments"), Value: aws.Float64(2)},
+	}}, nil
+}
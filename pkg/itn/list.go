@@ -0,0 +1,185 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/fis"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	"github.com/samber/lo"
+)
+
+// Listing is an interruption experiment along with who started it
+type Listing struct {
+	Experiment types.Experiment
+	// StartedBy is the ARN of the caller that started the experiment, if it was started by the interrupter
+	StartedBy string
+}
+
+// InstanceIDs returns the sorted instance IDs targeted by the experiment
+func (l Listing) InstanceIDs() []string {
+	instanceIDs := ExperimentInstanceIDs(l.Experiment)
+	sort.Strings(instanceIDs)
+	return instanceIDs
+}
+
+// Active reports whether the experiment is still pending, initiating or running
+func (l Listing) Active() bool {
+	return l.Experiment.State != nil && isActive(l.Experiment.State.Status)
+}
+
+// Experiments returns the experiments in the account and region that send Spot ITNs, most recent first. Only the
+// experiments with the status are returned, unless it's empty.
+func (i ITN) Experiments(ctx context.Context, status types.ExperimentStatus) ([]Listing, error) {
+	templates, err := i.spotITNTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	experiments, err := i.spotITNExperiments(ctx, templates, func(summary types.ExperimentSummary) bool {
+		return status == "" || (summary.State != nil && strings.EqualFold(string(summary.State.Status), string(status)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	listings := lo.Map(experiments, func(experiment types.Experiment, _ int) Listing {
+		startedBy, ok := experiment.Tags[createdByTag]
+		if !ok {
+			startedBy = templates[lo.FromPtr(experiment.ExperimentTemplateId)]
+		}
+		return Listing{Experiment: experiment, StartedBy: startedBy}
+	})
+	sort.SliceStable(listings, func(a, b int) bool {
+		return lo.FromPtr(listings[a].Experiment.CreationTime).After(lo.FromPtr(listings[b].Experiment.CreationTime))
+	})
+	return listings, nil
+}
+
+// Stop stops a running experiment. The instances already sent an ITN are still interrupted.
+func (i ITN) Stop(ctx context.Context, experimentID string) error {
+	_, err := i.fisClient.StopExperiment(ctx, &fis.StopExperimentInput{Id: &experimentID})
+	return err
+}
+
+// LeftoverTemplates returns the experiment templates the interrupter created that weren't cleaned up, like when a
+// run exited early or with --clean=false. Persistent templates and the templates of active experiments are kept.
+func (i ITN) LeftoverTemplates(ctx context.Context) ([]types.ExperimentTemplateSummary, error) {
+	active := map[string]bool{}
+	experiments := fis.NewListExperimentsPaginator(i.fisClient, &fis.ListExperimentsInput{})
+	for experiments.HasMorePages() {
+		out, err := experiments.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, summary := range out.Experiments {
+			if summary.State != nil && isActive(summary.State.Status) {
+				active[lo.FromPtr(summary.ExperimentTemplateId)] = true
+			}
+		}
+	}
+	var templates []types.ExperimentTemplateSummary
+	paginator := fis.NewListExperimentTemplatesPaginator(i.fisClient, &fis.ListExperimentTemplatesInput{})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, template := range out.ExperimentTemplates {
+			_, created := template.Tags[createdByTag]
+			_, persistent := template.Tags[templateNameTag]
+			if created && !persistent && !active[lo.FromPtr(template.Id)] {
+				templates = append(templates, template)
+			}
+		}
+	}
+	sort.SliceStable(templates, func(a, b int) bool {
+		return lo.FromPtr(templates[a].CreationTime).After(lo.FromPtr(templates[b].CreationTime))
+	})
+	return templates, nil
+}
+
+// DeleteTemplate deletes an experiment template, like a leftover one
+func (i ITN) DeleteTemplate(ctx context.Context, templateID string) error {
+	_, err := i.fisClient.DeleteExperimentTemplate(ctx, &fis.DeleteExperimentTemplateInput{Id: &templateID})
+	return err
+}
+
+// spotITNTemplates returns who created each of the experiment templates that send Spot ITNs by template ID, or "" for
+// those created by others. The interrupter only creates Spot ITN templates, so only the others' are described.
+func (i ITN) spotITNTemplates(ctx context.Context) (map[string]string, error) {
+	templates := map[string]string{}
+	paginator := fis.NewListExperimentTemplatesPaginator(i.fisClient, &fis.ListExperimentTemplatesInput{})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, template := range out.ExperimentTemplates {
+			if creator, ok := template.Tags[createdByTag]; ok {
+				templates[lo.FromPtr(template.Id)] = creator
+				continue
+			}
+			described, err := i.fisClient.GetExperimentTemplate(ctx, &fis.GetExperimentTemplateInput{Id: template.Id})
+			if err != nil {
+				return nil, err
+			}
+			if lo.ContainsBy(lo.Values(described.ExperimentTemplate.Actions), func(action types.ExperimentTemplateAction) bool {
+				return lo.FromPtr(action.ActionId) == SpotITNAction
+			}) {
+				templates[lo.FromPtr(template.Id)] = ""
+			}
+		}
+	}
+	return templates, nil
+}
+
+// spotITNExperiments returns the experiments whose summaries match, only describing the ones the interrupter started
+// or that were started from one of the Spot ITN templates. The experiments others started from a Spot ITN template
+// that has since been deleted are missed.
+func (i ITN) spotITNExperiments(ctx context.Context, templates map[string]string, match func(types.ExperimentSummary) bool) ([]types.Experiment, error) {
+	var experiments []types.Experiment
+	paginator := fis.NewListExperimentsPaginator(i.fisClient, &fis.ListExperimentsInput{})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, summary := range out.Experiments {
+			_, started := summary.Tags[createdByTag]
+			_, fromTemplate := templates[lo.FromPtr(summary.ExperimentTemplateId)]
+			if !(started || fromTemplate) || !match(summary) {
+				continue
+			}
+			experiment, err := i.fisClient.GetExperiment(ctx, &fis.GetExperimentInput{Id: summary.Id})
+			if err != nil {
+				return nil, err
+			}
+			experiments = append(experiments, *experiment.Experiment)
+		}
+	}
+	return experiments, nil
+}
+
+// activeSpotITNExperiments returns the experiments sending Spot ITNs in the account and region that haven't finished yet
+func (i ITN) activeSpotITNExperiments(ctx context.Context) ([]types.Experiment, error) {
+	templates, err := i.spotITNTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return i.spotITNExperiments(ctx, templates, func(summary types.ExperimentSummary) bool {
+		return summary.State != nil && isActive(summary.State.Status)
+	})
+}
@@ -0,0 +1,103 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PollOptions configure how a watcher polls what happens to the interrupted instances after the interruption
+// notification. Zero fields take the watcher's defaults.
+type PollOptions struct {
+	// Interval is how often to poll
+	Interval time.Duration
+	// Timeout is how long to keep polling once the experiments are done
+	Timeout time.Duration
+}
+
+// WithDefaults fills the fields that aren't set with the defaults
+func (o PollOptions) WithDefaults(interval time.Duration, timeout time.Duration) PollOptions {
+	if o.Interval <= 0 {
+		o.Interval = interval
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = timeout
+	}
+	return o
+}
+
+// Poller polls in the background for a watcher until each of its polls is done, or the timeout has passed once the
+// experiments are done
+type Poller struct {
+	opts PollOptions
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewPoller(opts PollOptions) *Poller {
+	return &Poller{opts: opts, stop: make(chan struct{})}
+}
+
+// Go calls poll right away and then every interval in the background, until it returns that it's done or an error,
+// which is passed to failed
+func (p *Poller) Go(ctx context.Context, poll func() (bool, error), failed func(error)) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.opts.Interval)
+		defer ticker.Stop()
+		for {
+			done, err := poll()
+			if err != nil {
+				failed(err)
+				return
+			}
+			if done {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Wait waits for the polls to be done, stopping them once the timeout has passed. It's called once the experiments
+// are done.
+func (p *Poller) Wait() {
+	timeout := time.AfterFunc(p.opts.Timeout, func() { close(p.stop) })
+	defer timeout.Stop()
+	p.wg.Wait()
+}
+
+// Since is how long after the interruption notification at start t is, rounded to the second
+func Since(start time.Time, t time.Time) time.Duration {
+	return t.Sub(start).Round(time.Second)
+}
+
+// Milestone reports when a step happened after the interruption notification at start, like "drained after 42s", or
+// that it didn't happen if t is zero
+func Milestone(name string, start time.Time, t time.Time) string {
+	if t.IsZero() {
+		return fmt.Sprintf("not %s", name)
+	}
+	return fmt.Sprintf("%s after %s", name, Since(start, t))
+}
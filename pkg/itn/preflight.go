@@ -0,0 +1,357 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/fis"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/samber/lo"
+)
+
+// requiredActions are the IAM actions the caller needs to run an interruption experiment
+var requiredActions = []string{
+	"ec2:DescribeInstances",
+	"fis:CreateExperimentTemplate",
+	"fis:StartExperiment",
+	"fis:GetExperiment",
+	"fis:DeleteExperimentTemplate",
+	// validation lists the active Spot ITN experiments to check the instances aren't already scheduled
+	"fis:ListExperiments",
+	"fis:ListExperimentTemplates",
+	"fis:GetExperimentTemplate",
+	"iam:CreateRole",
+	"iam:PutRolePolicy",
+	"iam:PassRole",
+}
+
+// defaultActiveExperimentsQuota is the default FIS quota for active experiments per account and region, used
+// when Service Quotas can't be queried
+const defaultActiveExperimentsQuota = 5
+
+type CheckStatus string
+
+const (
+	CheckPassed  CheckStatus = "✅"
+	CheckWarning CheckStatus = "⚠️"
+	CheckFailed  CheckStatus = "❌"
+)
+
+// Check is the outcome of a single pre-flight check
+type Check struct {
+	Name    string
+	Status  CheckStatus
+	Message string
+}
+
+// Checks are the outcomes of all the pre-flight checks
+type Checks []Check
+
+// Err returns an error listing the failed checks, or nil if none failed
+func (c Checks) Err() error {
+	failed := lo.Filter(c, func(check Check, _ int) bool { return check.Status == CheckFailed })
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("pre-flight checks failed: %s", strings.Join(lo.Map(failed, func(check Check, _ int) string { return check.Name }), ", "))
+}
+
+// Preflight checks that an interruption experiment is able to run in the ITN's account and region before
+// anything is created, so that IAM and FIS problems surface up front rather than mid-run. Instance checks
+// are skipped if no instance IDs are passed.
+func (i ITN) Preflight(ctx context.Context, instanceIDs []string) Checks {
+	checks := Checks{
+		i.checkFISAvailability(ctx),
+		i.checkCallerPermissions(ctx),
+		i.checkFISRole(ctx),
+	}
+	// the checks needing the active experiments fail if they can't be listed, the others still run
+	activeExperiments, listErr := i.activeExperiments(ctx)
+	if listErr != nil {
+		checks = append(checks, Check{Name: "FIS experiment quota", Status: CheckFailed, Message: fmt.Sprintf("unable to list the active experiments: %v", listErr)})
+	} else {
+		checks = append(checks, i.checkExperimentQuota(ctx, activeExperiments))
+	}
+	if len(instanceIDs) > 0 {
+		checks = append(checks, i.checkInstancesFound(ctx, instanceIDs))
+		if listErr != nil {
+			checks = append(checks, Check{Name: "Instances not in active experiments", Status: CheckFailed, Message: fmt.Sprintf("unable to list the active experiments: %v", listErr)})
+		} else {
+			checks = append(checks, i.checkInstancesNotInExperiments(instanceIDs, activeExperiments))
+		}
+	}
+	return checks
+}
+
+func (i ITN) checkFISAvailability(ctx context.Context) Check {
+	check := Check{Name: "FIS availability"}
+	if _, err := i.fisClient.GetAction(ctx, &fis.GetActionInput{Id: aws.String(SpotITNAction)}); err != nil {
+		check.Status = CheckFailed
+		check.Message = fmt.Sprintf("%s is not available in %s: %v", SpotITNAction, i.cfg.Region, err)
+		return check
+	}
+	check.Status = CheckPassed
+	check.Message = fmt.Sprintf("%s is available in %s", SpotITNAction, i.cfg.Region)
+	return check
+}
+
+func (i ITN) checkCallerPermissions(ctx context.Context) Check {
+	check := Check{Name: "Caller permissions"}
+	principalARN, err := i.callerPrincipalARN(ctx)
+	if err != nil {
+		check.Status = CheckWarning
+		check.Message = fmt.Sprintf("unable to determine the caller's IAM principal: %v", err)
+		return check
+	}
+	if strings.HasSuffix(principalARN, ":root") {
+		check.Status = CheckPassed
+		check.Message = "the account root user is allowed all actions"
+		return check
+	}
+	var denied []string
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(i.iamClient, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principalARN),
+		ActionNames:     requiredActions,
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			check.Status = CheckWarning
+			check.Message = fmt.Sprintf("unable to simulate the policies of %s: %v", principalARN, err)
+			return check
+		}
+		for _, result := range out.EvaluationResults {
+			if result.EvalDecision != iamtypes.PolicyEvaluationDecisionTypeAllowed {
+				denied = append(denied, fmt.Sprintf("%s (%s)", *result.EvalActionName, result.EvalDecision))
+			}
+		}
+	}
+	if len(denied) > 0 {
+		check.Status = CheckFailed
+		check.Message = fmt.Sprintf("%s is not allowed %s", principalARN, strings.Join(denied, ", "))
+		return check
+	}
+	check.Status = CheckPassed
+	check.Message = fmt.Sprintf("%s is allowed all required actions", principalARN)
+	return check
+}
+
+// callerPrincipalARN returns the ARN of the IAM user or role behind the caller's credentials, since
+// assumed role session ARNs can't be simulated
+func (i ITN) callerPrincipalARN(ctx context.Context) (string, error) {
+	identity, err := i.stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	// arn:aws:sts::123456789012:assumed-role/role-name/session-name
+	arn := *identity.Arn
+	if !strings.Contains(arn, ":assumed-role/") {
+		return arn, nil
+	}
+	roleName := strings.Split(arn, "/")[1]
+	role, err := i.iamClient.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(roleName)})
+	if err != nil {
+		return "", err
+	}
+	return *role.Role.Arn, nil
+}
+
+func (i ITN) checkFISRole(ctx context.Context) Check {
+	check := Check{Name: "FIS role"}
+	role, err := i.iamClient.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(fisRoleName)})
+	var noSuchEntity *iamtypes.NoSuchEntityException
+	if errors.As(err, &noSuchEntity) {
+		check.Status = CheckPassed
+		check.Message = fmt.Sprintf("%s does not exist yet and will be created", fisRoleName)
+		return check
+	}
+	if err != nil {
+		check.Status = CheckWarning
+		check.Message = fmt.Sprintf("unable to get %s: %v", fisRoleName, err)
+		return check
+	}
+	trust, err := url.QueryUnescape(aws.ToString(role.Role.AssumeRolePolicyDocument))
+	if err != nil || !strings.Contains(trust, "fis.amazonaws.com") {
+		check.Status = CheckFailed
+		check.Message = fmt.Sprintf("%s does not trust fis.amazonaws.com", fisRoleName)
+		return check
+	}
+	policy, err := i.iamClient.GetRolePolicy(ctx, &iam.GetRolePolicyInput{
+		RoleName:   aws.String(fisRoleName),
+		PolicyName: aws.String(fmt.Sprintf("%s-policy", fisRoleName)),
+	})
+	if err != nil {
+		check.Status = CheckFailed
+		check.Message = fmt.Sprintf("unable to get the inline policy of %s: %v", fisRoleName, err)
+		return check
+	}
+	document, err := url.QueryUnescape(aws.ToString(policy.PolicyDocument))
+	if err != nil || !strings.Contains(document, "ec2:SendSpotInstanceInterruptions") {
+		check.Status = CheckFailed
+		check.Message = fmt.Sprintf("the inline policy of %s does not allow ec2:SendSpotInstanceInterruptions", fisRoleName)
+		return check
+	}
+	check.Status = CheckPassed
+	check.Message = fmt.Sprintf("%s trusts FIS and allows ec2:SendSpotInstanceInterruptions", *role.Role.Arn)
+	return check
+}
+
+func (i ITN) checkExperimentQuota(ctx context.Context, activeExperiments []types.Experiment) Check {
+	check := Check{Name: "FIS experiment quota"}
+	quota, err := i.activeExperimentsQuota(ctx)
+	if err != nil {
+		quota = defaultActiveExperimentsQuota
+		check.Status = CheckWarning
+		check.Message = fmt.Sprintf("unable to get the quota from Service Quotas, assuming the default of %d: %v. ", quota, err)
+	}
+	if len(activeExperiments) >= quota {
+		check.Status = CheckFailed
+		check.Message += fmt.Sprintf("%d of %d active experiments are already running", len(activeExperiments), quota)
+		return check
+	}
+	if check.Status == "" {
+		check.Status = CheckPassed
+	}
+	check.Message += fmt.Sprintf("%d of %d active experiments are running", len(activeExperiments), quota)
+	return check
+}
+
+func (i ITN) activeExperimentsQuota(ctx context.Context) (int, error) {
+	paginator := servicequotas.NewListServiceQuotasPaginator(i.quotasClient, &servicequotas.ListServiceQuotasInput{ServiceCode: aws.String("fis")})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, quota := range out.Quotas {
+			name := strings.ToLower(aws.ToString(quota.QuotaName))
+			if strings.Contains(name, "experiments") && (strings.Contains(name, "active") || strings.Contains(name, "concurrent")) && quota.Value != nil {
+				return int(*quota.Value), nil
+			}
+		}
+	}
+	return 0, errors.New("no active experiments quota found")
+}
+
+// activeExperiments returns all the experiments in the account and region that have not finished yet
+func (i ITN) activeExperiments(ctx context.Context) ([]types.Experiment, error) {
+	var experiments []types.Experiment
+	paginator := fis.NewListExperimentsPaginator(i.fisClient, &fis.ListExperimentsInput{})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, summary := range out.Experiments {
+			if summary.State == nil || !isActive(summary.State.Status) {
+				continue
+			}
+			experiment, err := i.fisClient.GetExperiment(ctx, &fis.GetExperimentInput{Id: summary.Id})
+			if err != nil {
+				return nil, err
+			}
+			experiments = append(experiments, *experiment.Experiment)
+		}
+	}
+	return experiments, nil
+}
+
+func isActive(status types.ExperimentStatus) bool {
+	return lo.Contains([]types.ExperimentStatus{
+		types.ExperimentStatusPending,
+		types.ExperimentStatusInitiating,
+		types.ExperimentStatusRunning,
+	}, status)
+}
+
+func (i ITN) checkInstancesFound(ctx context.Context, instanceIDs []string) Check {
+	check := Check{Name: "Instances found"}
+	instances, err := i.describeInstances(ctx, instanceIDs)
+	if err != nil {
+		check.Status = CheckFailed
+		check.Message = err.Error()
+		return check
+	}
+	found := lo.Map(instances, func(instance ec2types.Instance, _ int) string { return *instance.InstanceId })
+	if notFound, _ := lo.Difference(instanceIDs, found); len(notFound) > 0 {
+		check.Status = CheckFailed
+		check.Message = fmt.Sprintf("%s not found in %s", strings.Join(notFound, ", "), i.cfg.Region)
+		return check
+	}
+	check.Status = CheckPassed
+	check.Message = fmt.Sprintf("all %d instances found in %s", len(instanceIDs), i.cfg.Region)
+	return check
+}
+
+func (i ITN) checkInstancesNotInExperiments(instanceIDs []string, activeExperiments []types.Experiment) Check {
+	check := Check{Name: "Instances not in active experiments"}
+	var conflicts []string
+	for _, experiment := range activeExperiments {
+		for _, instanceID := range lo.Intersect(instanceIDs, ExperimentInstanceIDs(experiment)) {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", instanceID, *experiment.Id))
+		}
+	}
+	if len(conflicts) > 0 {
+		check.Status = CheckFailed
+		check.Message = fmt.Sprintf("already targeted by an active experiment: %s", strings.Join(conflicts, ", "))
+		return check
+	}
+	check.Status = CheckPassed
+	check.Message = "no instances are targeted by an active experiment"
+	return check
+}
+
+// ExperimentInstanceIDs returns the IDs of the EC2 instances targeted by ARN in an experiment
+func ExperimentInstanceIDs(experiment types.Experiment) []string {
+	var instanceIDs []string
+	for _, target := range experiment.Targets {
+		for _, arn := range target.ResourceArns {
+			if strings.Contains(arn, ":instance/") {
+				instanceIDs = append(instanceIDs, ARNToInstanceID(arn))
+			}
+		}
+	}
+	return instanceIDs
+}
+
+// describeInstances returns the instances with the IDs that exist. Unlike passing InstanceIds, filtering
+// on instance-id omits IDs that don't exist instead of failing the whole request.
+func (i ITN) describeInstances(ctx context.Context, instanceIDs []string) ([]ec2types.Instance, error) {
+	paginator := ec2.NewDescribeInstancesPaginator(i.ec2Client, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{{Name: aws.String("instance-id"), Values: instanceIDs}},
+	})
+	var instances []ec2types.Instance
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range out.Reservations {
+			instances = append(instances, r.Instances...)
+		}
+	}
+	return instances, nil
+}
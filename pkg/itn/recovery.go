@@ -0,0 +1,174 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+)
+
+const (
+	EventReplacementLaunched EventType = "ReplacementLaunched"
+	EventCapacityRestored    EventType = "CapacityRestored"
+	EventRecoveryReport      EventType = "RecoveryReport"
+)
+
+const (
+	recoveryPollInterval = 5 * time.Second
+	// recoveryTimeout is how long to wait for interrupted capacity to be replaced once the experiments are done
+	recoveryTimeout = 10 * time.Minute
+)
+
+// scopeTags are the tags of the groups that replace interrupted Spot instances, in order of preference
+var scopeTags = []string{"aws:autoscaling:groupName", "aws:ec2:fleet-id", "karpenter.sh/nodepool"}
+
+// recovery is the replacement of the interrupted capacity of a scope of Spot instances
+type recovery struct {
+	scope map[string]string
+	// baseline are the Spot instances running in the scope before the interruption
+	baseline []string
+	start    time.Time
+	launched time.Time
+	restored time.Time
+}
+
+// RecoveryWatcher measures how quickly interrupted capacity is replaced. The running Spot instances with all of the
+// tags are counted before the interruption, or those of the Auto Scaling groups, EC2 Fleets or Karpenter NodePools
+// the instances interrupted by the runs belong to if there are no tags. After the interruption notification the scopes are polled
+// until a replacement is launched and the count is restored without the interrupted instances, and the time to
+// restore capacity is reported as the MTTR.
+func (i ITN) RecoveryWatcher(ctx context.Context, tags map[string]string, opts PollOptions) Watcher {
+	return func(runs []Run, events <-chan Event) <-chan Event {
+		out := make(chan Event, 10)
+		go func() {
+			defer close(out)
+			// the runs list the instances they interrupt even when their experiments target them by tags
+			interrupted := lo.FlatMap(runs, func(run Run, _ int) []string { return run.InstanceIDs })
+			recoveries, err := i.recoveries(ctx, tags, interrupted)
+			if err != nil {
+				out <- Event{Type: EventError, Message: fmt.Sprintf("❌ Counting Spot capacity: %s", err), Timestamp: time.Now()}
+			}
+			poller := NewPoller(opts.WithDefaults(recoveryPollInterval, recoveryTimeout))
+			watching := false
+			for event := range events {
+				out <- event
+				if event.Type == EventInterruptionNotification && !watching && len(recoveries) > 0 {
+					for _, r := range recoveries {
+						r.start = event.Timestamp
+					}
+					watching = true
+					poller.Go(ctx, func() (bool, error) { return i.pollRecovery(ctx, recoveries, interrupted, out) }, func(err error) {
+						out <- Event{Type: EventError, Message: fmt.Sprintf("❌ Counting Spot capacity: %s", err), Timestamp: time.Now()}
+					})
+				}
+			}
+			if !watching {
+				return
+			}
+			poller.Wait()
+			for _, r := range recoveries {
+				out <- Event{Type: EventRecoveryReport, Message: r.report(), Timestamp: time.Now()}
+			}
+		}()
+		return out
+	}
+}
+
+// recoveries counts the running Spot instances of each scope before the interruption
+func (i ITN) recoveries(ctx context.Context, tags map[string]string, interrupted []string) ([]*recovery, error) {
+	scopes := []map[string]string{tags}
+	if len(tags) == 0 {
+		if len(interrupted) == 0 {
+			return nil, nil
+		}
+		instances, err := i.describeInstances(ctx, interrupted)
+		if err != nil {
+			return nil, err
+		}
+		scopes = lo.UniqBy(lo.FilterMap(instances, func(instance ec2types.Instance, _ int) (map[string]string, bool) {
+			return instanceScope(instance)
+		}), formatScope)
+	}
+	var recoveries []*recovery
+	for _, scope := range scopes {
+		instances, err := i.SpotInstancesWithTags(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		recoveries = append(recoveries, &recovery{scope: scope, baseline: lo.Map(instances, func(instance ec2types.Instance, _ int) string {
+			return *instance.InstanceId
+		})})
+	}
+	return recoveries, nil
+}
+
+// instanceScope is the tag of the group that replaces the instance when it's interrupted, if any
+func instanceScope(instance ec2types.Instance) (map[string]string, bool) {
+	for _, key := range scopeTags {
+		if tag, ok := lo.Find(instance.Tags, func(tag ec2types.Tag) bool { return lo.FromPtr(tag.Key) == key }); ok {
+			return map[string]string{key: lo.FromPtr(tag.Value)}, true
+		}
+	}
+	return nil, false
+}
+
+// pollRecovery counts the running Spot instances of the scopes, returning whether their capacity is all restored
+func (i ITN) pollRecovery(ctx context.Context, recoveries []*recovery, interrupted []string, out chan<- Event) (bool, error) {
+	for _, r := range recoveries {
+		if !r.restored.IsZero() {
+			continue
+		}
+		instances, err := i.SpotInstancesWithTags(ctx, r.scope)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", formatScope(r.scope), err)
+		}
+		running := lo.Without(lo.Map(instances, func(instance ec2types.Instance, _ int) string { return *instance.InstanceId }), interrupted...)
+		now := time.Now()
+		if replacements := lo.Without(running, r.baseline...); r.launched.IsZero() && len(replacements) > 0 {
+			r.launched = now
+			out <- Event{Type: EventReplacementLaunched, Message: fmt.Sprintf("🚀 Replacement %s launched in %s %s after the interruption notification", strings.Join(replacements, ", "), formatScope(r.scope), Since(r.start, now)), Timestamp: now}
+		}
+		if len(running) >= len(r.baseline) {
+			r.restored = now
+			out <- Event{Type: EventCapacityRestored, Message: fmt.Sprintf("✅ Capacity of %s restored to %d Spot instances %s after the interruption notification", formatScope(r.scope), len(running), Since(r.start, now)), Timestamp: now}
+		}
+	}
+	return lo.EveryBy(recoveries, func(r *recovery) bool { return !r.restored.IsZero() }), nil
+}
+
+// report summarizes the time from the interruption notification to the replacement launching and the capacity being
+// restored, the MTTR
+func (r *recovery) report() string {
+	launched := "no replacement launched"
+	if !r.launched.IsZero() {
+		launched = fmt.Sprintf("replacement launched after %s", Since(r.start, r.launched))
+	}
+	restored := fmt.Sprintf("capacity not restored to %d Spot instances", len(r.baseline))
+	if !r.restored.IsZero() {
+		restored = fmt.Sprintf("capacity restored to %d Spot instances, MTTR %s", len(r.baseline), Since(r.start, r.restored))
+	}
+	return fmt.Sprintf("📋 Recovery of %s: %s, %s", formatScope(r.scope), launched, restored)
+}
+
+func formatScope(scope map[string]string) string {
+	pairs := lo.MapToSlice(scope, func(key string, value string) string { return fmt.Sprintf("%s=%s", key, value) })
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
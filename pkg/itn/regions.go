@@ -0,0 +1,221 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+)
+
+// Run is an FIS experiment along with the region and account it was started in, and the instances it interrupts
+type Run struct {
+	Region      string
+	AccountID   string
+	Experiment  *types.Experiment
+	InstanceIDs []string
+}
+
+// MultiRegion fans interruptions out to an ITN per region
+type MultiRegion struct {
+	itns map[string]*ITN
+}
+
+func NewMultiRegion(cfg aws.Config, regions []string) *MultiRegion {
+	itns := map[string]*ITN{}
+	for _, region := range regions {
+		regionalCfg := cfg.Copy()
+		regionalCfg.Region = region
+		itns[region] = New(regionalCfg)
+	}
+	return &MultiRegion{itns: itns}
+}
+
+// Regions returns the sorted regions the MultiRegion can interrupt instances in
+func (m MultiRegion) Regions() []string {
+	regions := lo.Keys(m.itns)
+	sort.Strings(regions)
+	return regions
+}
+
+// Interrupt starts an FIS experiment in each region of targets concurrently and merges the event streams
+// of all the experiments, labeling each event with its region. A region that fails to start is reported
+// in the event stream as long as at least one other region started.
+func (m MultiRegion) Interrupt(ctx context.Context, targets map[string][]string, opts Options) ([]Run, <-chan Event, error) {
+	if len(targets) == 0 {
+		return nil, nil, errors.New("no instances specified")
+	}
+	// validate every region up front so that no experiments are started if any of the targets are invalid, and
+	// the regions don't validate them again
+	valid, err := m.Validate(ctx, targets)
+	if err != nil {
+		return nil, nil, err
+	}
+	regions := lo.Keys(valid)
+	sort.Strings(regions)
+	type result struct {
+		experiment *types.Experiment
+		events     <-chan Event
+		err        error
+	}
+	results := make([]result, len(regions))
+	var wg sync.WaitGroup
+	for j, region := range regions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			experiment, events, err := m.itns[region].interrupt(ctx, valid[region], opts, true)
+			results[j] = result{experiment: experiment, events: events, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var runs []Run
+	var streams []<-chan Event
+	var errs error
+	failures := make(chan Event, len(regions))
+	for j, region := range regions {
+		if results[j].err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("%s: %w", region, results[j].err))
+			failures <- Event{
+				Timestamp: time.Now(),
+				Type:      EventError,
+				Message:   fmt.Sprintf("❌ Error starting interruptions: %v", results[j].err),
+				Region:    region,
+			}
+			continue
+		}
+		runs = append(runs, Run{Region: region, Experiment: results[j].experiment, InstanceIDs: valid[region]})
+		streams = append(streams, withRegion(results[j].events, region))
+	}
+	close(failures)
+	if len(runs) == 0 {
+		return nil, nil, errs
+	}
+	return runs, Merge(append([]<-chan Event{failures}, streams...)...), nil
+}
+
+// Preflight runs the pre-flight checks in each region of targets
+func (m MultiRegion) Preflight(ctx context.Context, targets map[string][]string) map[string]Checks {
+	checks := map[string]Checks{}
+	for region, instanceIDs := range targets {
+		if itn, ok := m.itns[region]; ok {
+			checks[region] = itn.Preflight(ctx, instanceIDs)
+		}
+	}
+	return checks
+}
+
+// SpotInstancesWithTags returns the IDs of the running Spot instances with all of the tags, grouped by region
+func (m MultiRegion) SpotInstancesWithTags(ctx context.Context, tags map[string]string) (map[string][]string, error) {
+	byRegion := map[string][]string{}
+	for _, region := range m.Regions() {
+		instances, err := m.itns[region].SpotInstancesWithTags(ctx, tags)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", region, err)
+		}
+		for _, instance := range instances {
+			byRegion[region] = append(byRegion[region], *instance.InstanceId)
+		}
+	}
+	return byRegion, nil
+}
+
+// Locate finds the region each of the instance IDs is running in
+func (m MultiRegion) Locate(ctx context.Context, instanceIDs []string) (map[string][]string, error) {
+	located := map[string][]string{}
+	remaining := lo.Uniq(instanceIDs)
+	for _, region := range m.Regions() {
+		if len(remaining) == 0 {
+			break
+		}
+		instances, err := m.itns[region].describeInstances(ctx, remaining)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", region, err)
+		}
+		for _, instance := range instances {
+			located[region] = append(located[region], *instance.InstanceId)
+		}
+		remaining, _ = lo.Difference(remaining, located[region])
+	}
+	if len(remaining) > 0 {
+		return nil, fmt.Errorf("instances %v were not found in regions %v", remaining, m.Regions())
+	}
+	return located, nil
+}
+
+// ParseInstanceSelectors splits instance IDs qualified with a region (us-west-2:i-0123456789abcdef0)
+// from unqualified instance IDs, grouping the qualified instance IDs by region.
+func ParseInstanceSelectors(selectors []string) (map[string][]string, []string) {
+	byRegion := map[string][]string{}
+	var unqualified []string
+	for _, selector := range selectors {
+		region, instanceID, ok := strings.Cut(selector, ":")
+		if !ok {
+			unqualified = append(unqualified, selector)
+			continue
+		}
+		byRegion[region] = append(byRegion[region], instanceID)
+	}
+	return byRegion, unqualified
+}
+
+// Merge combines event streams into a single stream that is closed once all of the streams are closed
+func Merge(streams ...<-chan Event) <-chan Event {
+	merged := make(chan Event, 10)
+	var wg sync.WaitGroup
+	for _, stream := range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for event := range stream {
+				merged <- event
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+	return merged
+}
+
+func withRegion(events <-chan Event, region string) <-chan Event {
+	return relabel(events, func(event *Event) { event.Region = region })
+}
+
+func withAccount(events <-chan Event, accountID string) <-chan Event {
+	return relabel(events, func(event *Event) { event.AccountID = accountID })
+}
+
+func relabel(events <-chan Event, label func(*Event)) <-chan Event {
+	labeled := make(chan Event, 10)
+	go func() {
+		defer close(labeled)
+		for event := range events {
+			label(&event)
+			labeled <- event
+		}
+	}()
+	return labeled
+}
@@ -0,0 +1,153 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/fis"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	"github.com/samber/lo"
+)
+
+// putPersistentTemplate creates the named template, or updates it if its definition changed, so that the same
+// template is reused for every interruption. The template selects the running Spot instances with the tags, which FIS
+// resolves when the experiment starts, so checkTagScope makes sure they're the validated instances first.
+func (i ITN) putPersistentTemplate(ctx context.Context, instanceIDs []string, opts Options, roleARN *string, tags map[string]string) (_ *string, err error) {
+	ctx, span := startSpan(ctx, "putPersistentTemplate", InstanceIDsAttribute.StringSlice(instanceIDs))
+	defer func() { endSpan(span, err) }()
+	if err := i.checkTagScope(ctx, instanceIDs, opts.Tags); err != nil {
+		return nil, err
+	}
+	templateID, err := i.findTemplate(ctx, opts.TemplateName)
+	if err != nil {
+		return nil, err
+	}
+	description := aws.String(fmt.Sprintf("trigger spot ITN for instances with tags %v", opts.Tags))
+	action := types.CreateExperimentTemplateActionInput{
+		ActionId:   aws.String(SpotITNAction),
+		Parameters: actionParameters(opts.Delay),
+		Targets:    map[string]string{"SpotInstances": "itn0"},
+	}
+	if templateID == nil {
+		out, err := i.fisClient.CreateExperimentTemplate(ctx, &fis.CreateExperimentTemplateInput{
+			Actions: map[string]types.CreateExperimentTemplateActionInput{"itn0": action},
+			Targets: map[string]types.CreateExperimentTemplateTargetInput{"itn0": {
+				ResourceType:  aws.String("aws:ec2:spot-instance"),
+				SelectionMode: aws.String("ALL"),
+				ResourceTags:  opts.Tags,
+				Filters:       runningFilter,
+			}},
+			StopConditions: stopConditions(opts.StopAlarms),
+			RoleArn:        roleARN,
+			Tags:           tags,
+			Description:    description,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return out.ExperimentTemplate.Id, nil
+	}
+	template, err := i.fisClient.GetExperimentTemplate(ctx, &fis.GetExperimentTemplateInput{Id: templateID})
+	if err != nil {
+		return nil, err
+	}
+	if persistentTemplateUpToDate(*template.ExperimentTemplate, opts, roleARN) {
+		return templateID, nil
+	}
+	_, err = i.fisClient.UpdateExperimentTemplate(ctx, &fis.UpdateExperimentTemplateInput{
+		Id: templateID,
+		Actions: map[string]types.UpdateExperimentTemplateActionInputItem{"itn0": {
+			ActionId:   action.ActionId,
+			Parameters: action.Parameters,
+			Targets:    action.Targets,
+		}},
+		Targets: map[string]types.UpdateExperimentTemplateTargetInput{"itn0": {
+			ResourceType:  aws.String("aws:ec2:spot-instance"),
+			SelectionMode: aws.String("ALL"),
+			ResourceTags:  opts.Tags,
+			Filters:       runningFilter,
+		}},
+		StopConditions: lo.Map(stopConditions(opts.StopAlarms), func(condition types.CreateExperimentTemplateStopConditionInput, _ int) types.UpdateExperimentTemplateStopConditionInput {
+			return types.UpdateExperimentTemplateStopConditionInput{Source: condition.Source, Value: condition.Value}
+		}),
+		RoleArn:     roleARN,
+		Description: description,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return templateID, nil
+}
+
+// runningFilter limits the target of a persistent template to running instances, like SpotInstancesWithTags
+var runningFilter = []types.ExperimentTemplateTargetInputFilter{{Path: aws.String("State.Name"), Values: []string{"running"}}}
+
+// checkTagScope returns an error unless the running Spot instances with the tags are exactly the validated instances,
+// since the persistent template would otherwise interrupt instances that were skipped or failed validation and the
+// guardrails, or more instances than FIS can target
+func (i ITN) checkTagScope(ctx context.Context, instanceIDs []string, tags map[string]string) error {
+	instances, err := i.SpotInstancesWithTags(ctx, tags)
+	if err != nil {
+		return err
+	}
+	tagged := lo.Map(instances, func(instance ec2types.Instance, _ int) string { return aws.ToString(instance.InstanceId) })
+	extra, missing := lo.Difference(tagged, instanceIDs)
+	if len(extra) > 0 {
+		return fmt.Errorf("the tags %v also select %v, which weren't validated for interruption, use the ephemeral mode to interrupt only some of the tagged instances", tags, extra)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the tags %v don't select %v, use the ephemeral mode to interrupt instances without the tags", tags, missing)
+	}
+	if len(tagged) > fisTargetLimit {
+		return fmt.Errorf("the tags %v select %d instances but a persistent template can target at most %d, use the ephemeral mode to interrupt more", tags, len(tagged), fisTargetLimit)
+	}
+	return nil
+}
+
+// persistentTemplateUpToDate returns whether the template already interrupts the instances with the tags with the
+// delay, stop conditions and role of the options, so it doesn't need to be updated
+func persistentTemplateUpToDate(template types.ExperimentTemplate, opts Options, roleARN *string) bool {
+	action, target := template.Actions["itn0"], template.Targets["itn0"]
+	alarms := lo.FilterMap(template.StopConditions, func(condition types.ExperimentTemplateStopCondition, _ int) (string, bool) {
+		return aws.ToString(condition.Value), aws.ToString(condition.Source) == "aws:cloudwatch:alarm"
+	})
+	return len(template.Actions) == 1 && len(template.Targets) == 1 &&
+		maps.Equal(action.Parameters, actionParameters(opts.Delay)) &&
+		maps.Equal(target.ResourceTags, opts.Tags) &&
+		slices.Equal(alarms, opts.StopAlarms) &&
+		aws.ToString(template.RoleArn) == aws.ToString(roleARN)
+}
+
+// findTemplate returns the ID of the persistent template with the name, or nil if there isn't one
+func (i ITN) findTemplate(ctx context.Context, name string) (*string, error) {
+	paginator := fis.NewListExperimentTemplatesPaginator(i.fisClient, &fis.ListExperimentTemplatesInput{})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, template := range out.ExperimentTemplates {
+			if template.Tags[templateNameTag] == name {
+				return template.Id, nil
+			}
+		}
+	}
+	return nil, nil
+}
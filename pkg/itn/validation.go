@@ -0,0 +1,148 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package itn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+)
+
+type InvalidReason string
+
+const (
+	ReasonNotFound    InvalidReason = "not found"
+	ReasonNotSpot     InvalidReason = "not a Spot instance"
+	ReasonNotRunning  InvalidReason = "not running"
+	ReasonWrongRegion InvalidReason = "in another region"
+	ReasonScheduled   InvalidReason = "already scheduled for interruption"
+)
+
+// InvalidInstance is an instance ID that can't be interrupted, along with why
+type InvalidInstance struct {
+	InstanceID string
+	Region     string
+	Reason     InvalidReason
+	// Detail is extra context for the reason, like the state of an instance that isn't running
+	Detail string
+}
+
+func (i InvalidInstance) String() string {
+	if i.Detail != "" {
+		return fmt.Sprintf("%s is %s (%s)", i.InstanceID, i.Reason, i.Detail)
+	}
+	return fmt.Sprintf("%s is %s", i.InstanceID, i.Reason)
+}
+
+// ValidationError lists every instance ID that failed validation
+type ValidationError struct {
+	Invalid []InvalidInstance
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(lo.Map(e.Invalid, func(invalid InvalidInstance, _ int) string { return invalid.String() }), "; ")
+}
+
+// Validate checks that each instance ID is a running Spot instance in the ITN's region that isn't already targeted
+// by an active experiment. The instance IDs that passed are returned along with a *ValidationError listing the
+// ones that did not, so that callers can choose to continue with the valid instances.
+func (i ITN) Validate(ctx context.Context, instanceIDs []string) ([]string, error) {
+	if len(instanceIDs) == 0 {
+		return nil, errors.New("no instances specified")
+	}
+	instances, err := i.describeInstances(ctx, instanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	var invalid []InvalidInstance
+	found := lo.Map(instances, func(instance ec2types.Instance, _ int) string { return *instance.InstanceId })
+	notFound, _ := lo.Difference(lo.Uniq(instanceIDs), found)
+	for _, instanceID := range notFound {
+		invalid = append(invalid, InvalidInstance{InstanceID: instanceID, Region: i.cfg.Region, Reason: ReasonNotFound})
+	}
+	for _, instance := range instances {
+		if instance.InstanceLifecycle != ec2types.InstanceLifecycleTypeSpot {
+			invalid = append(invalid, InvalidInstance{InstanceID: *instance.InstanceId, Region: i.cfg.Region, Reason: ReasonNotSpot})
+		}
+		if instance.State.Name != ec2types.InstanceStateNameRunning {
+			invalid = append(invalid, InvalidInstance{InstanceID: *instance.InstanceId, Region: i.cfg.Region, Reason: ReasonNotRunning, Detail: string(instance.State.Name)})
+		}
+	}
+	activeExperiments, err := i.activeSpotITNExperiments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing the active experiments: %w", err)
+	}
+	for _, experiment := range activeExperiments {
+		for _, instanceID := range lo.Intersect(found, ExperimentInstanceIDs(experiment)) {
+			invalid = append(invalid, InvalidInstance{InstanceID: instanceID, Region: i.cfg.Region, Reason: ReasonScheduled, Detail: *experiment.Id})
+		}
+	}
+	if len(invalid) == 0 {
+		return instanceIDs, nil
+	}
+	invalidIDs := lo.Map(invalid, func(invalid InvalidInstance, _ int) string { return invalid.InstanceID })
+	valid, _ := lo.Difference(instanceIDs, invalidIDs)
+	return valid, &ValidationError{Invalid: invalid}
+}
+
+// Validate validates the instance IDs of each region in targets, returning the valid instance IDs grouped by region
+// along with a *ValidationError listing the invalid ones. Instance IDs that aren't found in their region but are
+// found in another one of the regions are reported as being in the wrong region.
+func (m MultiRegion) Validate(ctx context.Context, targets map[string][]string) (map[string][]string, error) {
+	regions := lo.Keys(targets)
+	sort.Strings(regions)
+	valid := map[string][]string{}
+	var invalid []InvalidInstance
+	for _, region := range regions {
+		itn, ok := m.itns[region]
+		if !ok {
+			return nil, fmt.Errorf("%s is not one of the regions %v", region, m.Regions())
+		}
+		regionValid, err := itn.Validate(ctx, targets[region])
+		var validationErr *ValidationError
+		if err != nil && !errors.As(err, &validationErr) {
+			return nil, fmt.Errorf("%s: %w", region, err)
+		}
+		if len(regionValid) > 0 {
+			valid[region] = regionValid
+		}
+		if validationErr != nil {
+			invalid = append(invalid, validationErr.Invalid...)
+		}
+	}
+	for j := range invalid {
+		if invalid[j].Reason != ReasonNotFound {
+			continue
+		}
+		for _, region := range m.Regions() {
+			if region == invalid[j].Region {
+				continue
+			}
+			if instances, err := m.itns[region].describeInstances(ctx, []string{invalid[j].InstanceID}); err == nil && len(instances) > 0 {
+				invalid[j].Reason = ReasonWrongRegion
+				invalid[j].Detail = region
+				break
+			}
+		}
+	}
+	if len(invalid) > 0 {
+		return valid, &ValidationError{Invalid: invalid}
+	}
+	return valid, nil
+}
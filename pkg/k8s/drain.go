@@ -0,0 +1,192 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	EventNodeCordoned itn.EventType = "NodeCordoned"
+	EventPodEvicted   itn.EventType = "PodEvicted"
+	EventNodeDrained  itn.EventType = "NodeDrained"
+	EventNodeDeleted  itn.EventType = "NodeDeleted"
+	EventDrainReport  itn.EventType = "DrainReport"
+)
+
+const (
+	drainPollInterval = 2 * time.Second
+	// deletionTimeout is how long to wait for interrupted nodes to be deleted once the experiments are done
+	deletionTimeout = 5 * time.Minute
+)
+
+// drain is what has happened to an interrupted node since the interruption notification
+type drain struct {
+	node      Node
+	region    string
+	start     time.Time
+	taints    []corev1.Taint
+	pods      map[string]bool
+	cordoned  time.Time
+	drained   time.Time
+	deleted   time.Time
+	mu        sync.Mutex
+	reporting chan itn.Event
+}
+
+// DrainWatcher verifies the nodes the runs interrupt are drained after their interruption notification. It watches
+// each node for being cordoned or tainted, its pods being evicted, and its deletion, and reports how long each took.
+// The run fails if a node isn't drained by the time its instance is shut down.
+func (c Cluster) DrainWatcher(ctx context.Context, nodes []Node, opts itn.PollOptions) itn.Watcher {
+	return func(runs []itn.Run, events <-chan itn.Event) <-chan itn.Event {
+		out := make(chan itn.Event, 10)
+		// nodes whose instances were skipped by validation aren't interrupted, so they aren't expected to drain
+		interrupted := lo.FlatMap(runs, func(run itn.Run, _ int) []string { return run.InstanceIDs })
+		nodes := lo.Filter(nodes, func(node Node, _ int) bool { return lo.Contains(interrupted, node.InstanceID) })
+		go func() {
+			defer close(out)
+			var drains []*drain
+			poller := itn.NewPoller(opts.WithDefaults(drainPollInterval, deletionTimeout))
+			for event := range events {
+				if event.Type == itn.EventInterruptionNotification {
+					for _, node := range nodes {
+						if !inRegion(event, node) || lo.ContainsBy(drains, func(d *drain) bool { return d.node == node }) {
+							continue
+						}
+						d := &drain{node: node, region: event.Region, start: event.Timestamp, pods: map[string]bool{}, reporting: out}
+						drains = append(drains, d)
+						// taints from before the interruption notification don't count as cordoning the node
+						if n, err := c.client.CoreV1().Nodes().Get(ctx, node.Name, metav1.GetOptions{}); err == nil {
+							d.taints = n.Spec.Taints
+						}
+						poller.Go(ctx, func() (bool, error) { return c.poll(ctx, d) }, func(err error) {
+							out <- d.event(itn.EventError, fmt.Sprintf("❌ Watching node %s: %s", d.node.Name, err))
+						})
+					}
+				}
+				out <- event
+				if event.Type == itn.EventShutdown {
+					for _, d := range drains {
+						if inRegion(event, d.node) && !d.isDrained() {
+							out <- d.event(itn.EventError, fmt.Sprintf("❌ Node %s wasn't drained before shutdown", d.node.Name))
+						}
+					}
+				}
+			}
+			poller.Wait()
+			for _, d := range drains {
+				out <- d.event(EventDrainReport, d.report())
+			}
+		}()
+		return out
+	}
+}
+
+func inRegion(event itn.Event, node Node) bool {
+	return event.Region == "" || node.Region == "" || event.Region == node.Region
+}
+
+// poll updates the drain with the node and its pods, returning whether the node has been deleted
+func (c Cluster) poll(ctx context.Context, d *drain) (bool, error) {
+	node, err := c.client.CoreV1().Nodes().Get(ctx, d.node.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		d.mu.Lock()
+		d.deleted = time.Now()
+		d.mu.Unlock()
+		d.reporting <- d.event(EventNodeDeleted, fmt.Sprintf("🗑️  Node %s deleted %s after the interruption notification", d.node.Name, itn.Since(d.start, d.deleted)))
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	pods, err := c.client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{FieldSelector: "spec.nodeName=" + d.node.Name})
+	if err != nil {
+		return false, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	if d.cordoned.IsZero() && (node.Spec.Unschedulable || len(d.newTaints(node)) > 0) {
+		d.cordoned = now
+		d.reporting <- d.event(EventNodeCordoned, fmt.Sprintf("🚧 Node %s cordoned %s after the interruption notification", d.node.Name, itn.Since(d.start, now)))
+	}
+	running := map[string]bool{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == d.node.Name && evictable(pod) {
+			key := pod.Namespace + "/" + pod.Name
+			d.pods[key] = true
+			running[key] = pod.DeletionTimestamp == nil
+		}
+	}
+	for key := range d.pods {
+		if !running[key] {
+			delete(d.pods, key)
+			d.reporting <- d.event(EventPodEvicted, fmt.Sprintf("🚚 Pod %s evicted from node %s %s after the interruption notification", key, d.node.Name, itn.Since(d.start, now)))
+		}
+	}
+	if !d.cordoned.IsZero() && d.drained.IsZero() && len(d.pods) == 0 {
+		d.drained = now
+		d.reporting <- d.event(EventNodeDrained, fmt.Sprintf("✅ Node %s drained %s after the interruption notification", d.node.Name, itn.Since(d.start, now)))
+	}
+	return false, nil
+}
+
+// newTaints are the NoSchedule and NoExecute taints added to the node since the interruption notification
+func (d *drain) newTaints(node *corev1.Node) []corev1.Taint {
+	return lo.Filter(node.Spec.Taints, func(taint corev1.Taint, _ int) bool {
+		return taint.Effect != corev1.TaintEffectPreferNoSchedule && !lo.ContainsBy(d.taints, func(t corev1.Taint) bool { return t.MatchTaint(&taint) })
+	})
+}
+
+// evictable pods are the ones a drain evicts, skipping DaemonSet and static pods along with the finished ones
+func evictable(pod corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return false
+	}
+	return !lo.ContainsBy(pod.OwnerReferences, func(owner metav1.OwnerReference) bool { return owner.Kind == "DaemonSet" })
+}
+
+func (d *drain) isDrained() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !d.drained.IsZero() || !d.deleted.IsZero()
+}
+
+func (d *drain) event(eventType itn.EventType, message string) itn.Event {
+	return itn.Event{Type: eventType, Message: message, Timestamp: time.Now(), Region: d.region}
+}
+
+// report summarizes the time from the interruption notification to each step of the drain
+func (d *drain) report() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return fmt.Sprintf("📋 Node %s (%s): %s", d.node.Name, d.node.InstanceID, strings.Join([]string{
+		itn.Milestone("cordoned", d.start, d.cordoned),
+		itn.Milestone("drained", d.start, d.drained),
+		itn.Milestone("deleted", d.start, d.deleted),
+	}, ", "))
+}
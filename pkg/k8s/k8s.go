@@ -0,0 +1,140 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// NodePoolLabel is the label Karpenter adds to the nodes it launches for a NodePool
+	NodePoolLabel = "karpenter.sh/nodepool"
+	regionLabel   = "topology.kubernetes.io/region"
+	zoneLabel     = "topology.kubernetes.io/zone"
+)
+
+// zoneRegion matches the region a zone is in, which prefixes availability zones like us-west-2a as well as Local and
+// Wavelength Zones like us-west-2-lax-1a and us-east-1-wl1-bos-wlz-1
+var zoneRegion = regexp.MustCompile(`^[a-z]+(-[a-z]+)+-\d+`)
+
+// Node is a Kubernetes node backed by an EC2 instance
+type Node struct {
+	Name       string
+	InstanceID string
+	Region     string
+}
+
+// Cluster selects the nodes of a Kubernetes cluster to interrupt
+type Cluster struct {
+	client kubernetes.Interface
+}
+
+// NewCluster connects to the cluster of a kubeconfig context. An empty kubeconfig follows the usual rules of
+// $KUBECONFIG and ~/.kube/config, and an empty context is the current context.
+func NewCluster(kubeconfig string, kubeContext string) (*Cluster, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewClusterFromClientset(client), nil
+}
+
+func NewClusterFromClientset(client kubernetes.Interface) *Cluster {
+	return &Cluster{client: client}
+}
+
+// Nodes returns the nodes matching the label selector that belong to the Karpenter NodePool, either of which may be
+// empty, mapped to the EC2 instances backing them. Matching nodes that aren't backed by EC2 instances are skipped,
+// with the reasons returned.
+func (c Cluster) Nodes(ctx context.Context, selector string, nodePool string) ([]Node, []error, error) {
+	labelSelector, err := labels.Parse(selector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing node selector %q: %w", selector, err)
+	}
+	if nodePool != "" {
+		requirement, err := labels.NewRequirement(NodePoolLabel, "=", []string{nodePool})
+		if err != nil {
+			return nil, nil, err
+		}
+		labelSelector = labelSelector.Add(*requirement)
+	}
+	list, err := c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, nil, fmt.Errorf("no nodes match %q", labelSelector.String())
+	}
+	var nodes []Node
+	var skipped []error
+	for _, node := range list.Items {
+		// nodes not backed by an EC2 instance, like Fargate or on-premises ones, can't be interrupted
+		if _, _, err := ParseProviderID(node.Spec.ProviderID); err != nil {
+			skipped = append(skipped, fmt.Errorf("node %s: %w", node.Name, err))
+			continue
+		}
+		n, err := toNode(node)
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 0 {
+		return nil, skipped, fmt.Errorf("no nodes matching %q are backed by EC2 instances", labelSelector.String())
+	}
+	return nodes, skipped, nil
+}
+
+func toNode(node corev1.Node) (Node, error) {
+	zone, instanceID, err := ParseProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return Node{}, fmt.Errorf("node %s: %w", node.Name, err)
+	}
+	region := node.Labels[regionLabel]
+	if region == "" {
+		if labeledZone := node.Labels[zoneLabel]; labeledZone != "" {
+			zone = labeledZone
+		}
+		if region = zoneRegion.FindString(zone); region == "" {
+			return Node{}, fmt.Errorf("node %s: unable to tell the region of zone %q, label it with %s", node.Name, zone, regionLabel)
+		}
+	}
+	return Node{Name: node.Name, InstanceID: instanceID, Region: region}, nil
+}
+
+// ParseProviderID splits the provider ID of a node running on EC2, like aws:///us-west-2a/i-0123456789abcdef0, into
+// its availability zone and instance ID
+func ParseProviderID(providerID string) (string, string, error) {
+	path, ok := strings.CutPrefix(providerID, "aws://")
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if !ok || len(parts) != 2 || !strings.HasPrefix(parts[1], "i-") {
+		return "", "", fmt.Errorf("%q is not the provider ID of an EC2 instance", providerID)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,167 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func node(name string, providerID string, labels map[string]string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       corev1.NodeSpec{ProviderID: providerID},
+	}
+}
+
+func TestNodes(t *testing.T) {
+	ctx := context.Background()
+	cluster := NewClusterFromClientset(fake.NewSimpleClientset(
+		node("spot-a", "aws:///us-west-2a/i-0a", map[string]string{"karpenter.sh/capacity-type": "spot", NodePoolLabel: "default"}),
+		node("spot-b", "aws:///us-west-2b/i-0b", map[string]string{"karpenter.sh/capacity-type": "spot", NodePoolLabel: "batch", regionLabel: "us-west-2"}),
+		node("on-demand", "aws:///us-west-2a/i-0c", map[string]string{"karpenter.sh/capacity-type": "on-demand", NodePoolLabel: "default"}),
+		node("spot-lax", "aws:///us-west-2-lax-1a/i-0d", map[string]string{"karpenter.sh/capacity-type": "spot", NodePoolLabel: "edge"}),
+		node("spot-bos", "aws:///us-east-1-wl1-bos-wlz-1/i-0e", map[string]string{"karpenter.sh/capacity-type": "spot", NodePoolLabel: "edge"}),
+		node("fargate", "aws:///us-west-2a/fargate-ip-10-0-0-1", map[string]string{"karpenter.sh/capacity-type": "spot", NodePoolLabel: "serverless"}),
+	))
+
+	nodes, skipped, err := cluster.Nodes(ctx, "karpenter.sh/capacity-type=spot", "")
+	h.Ok(t, err)
+	h.Equals(t, []Node{
+		{Name: "spot-a", InstanceID: "i-0a", Region: "us-west-2"},
+		{Name: "spot-b", InstanceID: "i-0b", Region: "us-west-2"},
+		{Name: "spot-bos", InstanceID: "i-0e", Region: "us-east-1"},
+		{Name: "spot-lax", InstanceID: "i-0d", Region: "us-west-2"},
+	}, nodes)
+	h.Equals(t, 1, len(skipped))
+	h.Assert(t, strings.HasPrefix(skipped[0].Error(), "node fargate:"), "expected the Fargate node to be skipped, got %v", skipped)
+
+	nodes, _, err = cluster.Nodes(ctx, "karpenter.sh/capacity-type=spot", "batch")
+	h.Ok(t, err)
+	h.Equals(t, []Node{{Name: "spot-b", InstanceID: "i-0b", Region: "us-west-2"}}, nodes)
+
+	_, skipped, err = cluster.Nodes(ctx, "", "serverless")
+	h.Nok(t, err)
+	h.Equals(t, 1, len(skipped))
+	_, _, err = cluster.Nodes(ctx, "", "gpu")
+	h.Nok(t, err)
+	_, _, err = cluster.Nodes(ctx, "not a selector!", "")
+	h.Nok(t, err)
+}
+
+func TestParseProviderID(t *testing.T) {
+	zone, instanceID, err := ParseProviderID("aws:///us-west-2a/i-0123456789abcdef0")
+	h.Ok(t, err)
+	h.Equals(t, "us-west-2a", zone)
+	h.Equals(t, "i-0123456789abcdef0", instanceID)
+
+	for _, providerID := range []string{"", "kind://docker/kind/kind-control-plane", "aws:///us-west-2a/fargate-ip-10-0-0-1"} {
+		_, _, err := ParseProviderID(providerID)
+		h.Nok(t, err)
+	}
+}
+
+func pod(name string, nodeName string, owner string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+	}
+	if owner != "" {
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: owner, Name: name}}
+	}
+	return pod
+}
+
+// nextEvent reads events until one of the type, failing the test if none arrives in time
+func nextEvent(t *testing.T, events <-chan itn.Event, eventType itn.EventType) itn.Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("events closed before a %s event", eventType)
+			}
+			if event.Type == eventType {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for a %s event", eventType)
+		}
+	}
+}
+
+func TestDrainWatcher(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(
+		node("spot-a", "aws:///us-west-2a/i-0a", nil),
+		node("spot-b", "aws:///us-west-2a/i-0b", nil),
+		node("spot-c", "aws:///us-west-2a/i-0c", nil),
+		pod("web", "spot-a", "ReplicaSet"),
+		pod("logs", "spot-a", "DaemonSet"),
+		pod("db", "spot-b", "StatefulSet"),
+	)
+	cluster := NewClusterFromClientset(client)
+	nodes := []Node{{Name: "spot-a", InstanceID: "i-0a", Region: "us-west-2"}, {Name: "spot-b", InstanceID: "i-0b", Region: "us-west-2"}, {Name: "spot-c", InstanceID: "i-0c", Region: "us-west-2"}}
+	// spot-c is selected but left out of the run, like a node whose instance was skipped by validation
+	runs := []itn.Run{{Region: "us-west-2", InstanceIDs: []string{"i-0a", "i-0b"}}}
+	events := make(chan itn.Event)
+	out := cluster.DrainWatcher(ctx, nodes, itn.PollOptions{Interval: 10 * time.Millisecond, Timeout: 100 * time.Millisecond})(runs, events)
+
+	events <- itn.Event{Type: itn.EventInterruptionNotification, Timestamp: time.Now()}
+	nextEvent(t, out, itn.EventInterruptionNotification)
+
+	// spot-a is cordoned and drained, leaving its DaemonSet pod, while spot-b is only tainted
+	a, err := client.CoreV1().Nodes().Get(ctx, "spot-a", metav1.GetOptions{})
+	h.Ok(t, err)
+	a.Spec.Unschedulable = true
+	_, err = client.CoreV1().Nodes().Update(ctx, a, metav1.UpdateOptions{})
+	h.Ok(t, err)
+	h.Ok(t, client.CoreV1().Pods("default").Delete(ctx, "web", metav1.DeleteOptions{}))
+	b, err := client.CoreV1().Nodes().Get(ctx, "spot-b", metav1.GetOptions{})
+	h.Ok(t, err)
+	b.Spec.Taints = []corev1.Taint{{Key: "karpenter.sh/disrupted", Effect: corev1.TaintEffectNoSchedule}}
+	_, err = client.CoreV1().Nodes().Update(ctx, b, metav1.UpdateOptions{})
+	h.Ok(t, err)
+
+	drained := nextEvent(t, out, EventNodeDrained)
+	h.Assert(t, strings.Contains(drained.Message, "spot-a"), "expected spot-a to be drained, got %q", drained.Message)
+
+	events <- itn.Event{Type: itn.EventShutdown, Timestamp: time.Now()}
+	failure := nextEvent(t, out, itn.EventError)
+	h.Equals(t, "❌ Node spot-b wasn't drained before shutdown", failure.Message)
+
+	h.Ok(t, client.CoreV1().Nodes().Delete(ctx, "spot-a", metav1.DeleteOptions{}))
+	nextEvent(t, out, EventNodeDeleted)
+	close(events)
+
+	var reports []string
+	for event := range out {
+		if event.Type == EventDrainReport {
+			reports = append(reports, event.Message)
+		}
+	}
+	// spot-c isn't watched, so it's neither failed nor reported
+	h.Equals(t, 2, len(reports))
+	h.Assert(t, strings.Contains(reports[0], "spot-a (i-0a): cordoned after") && !strings.Contains(reports[0], "not "), "unexpected report %q", reports[0])
+	h.Assert(t, strings.Contains(reports[1], "spot-b (i-0b): cordoned after") && strings.Contains(reports[1], "not drained, not deleted"), "unexpected report %q", reports[1])
+}
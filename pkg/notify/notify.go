@@ -0,0 +1,240 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/samber/lo"
+)
+
+const EventNotificationFailed itn.EventType = "NotificationFailed"
+
+// DefaultTemplate labels the message with where it came from when the events are merged from multiple regions or
+// accounts
+const DefaultTemplate = `{{if .Scope}}[{{.Scope}}] {{end}}{{.Message}}`
+
+// DefaultEvents are the milestones of an interruption, and its failures
+var DefaultEvents = []itn.EventType{
+	itn.EventRebalanceRecommendation,
+	itn.EventInterruptionNotification,
+	itn.EventShutdown,
+	itn.EventError,
+}
+
+// backoff is how long to wait before retrying a failed notification, doubling with every attempt
+var backoff = time.Second
+
+// Notification is what the message template is executed with, an event and the experiments it's part of
+type Notification struct {
+	itn.Event
+	ExperimentIDs []string
+}
+
+// Sink is a channel notifications are posted to
+type Sink struct {
+	Name string
+	URL  string
+	// payload is the JSON body posted for a notification with the rendered text
+	payload func(text string, notification Notification) any
+}
+
+// Webhook posts the event as JSON along with the rendered text, for anything that can receive a webhook
+func Webhook(url string) Sink {
+	return Sink{Name: "webhook", URL: url, payload: func(text string, n Notification) any {
+		return map[string]any{
+			"type":          n.Type,
+			"text":          text,
+			"message":       n.Message,
+			"timestamp":     n.Timestamp,
+			"region":        n.Region,
+			"accountId":     n.AccountID,
+			"experimentIds": n.ExperimentIDs,
+		}
+	}}
+}
+
+// Slack posts the text to a Slack incoming webhook
+func Slack(url string) Sink {
+	return Sink{Name: "Slack", URL: url, payload: func(text string, _ Notification) any {
+		return map[string]any{"text": text}
+	}}
+}
+
+// Teams posts the text to a Microsoft Teams incoming webhook as a message card
+func Teams(url string) Sink {
+	return Sink{Name: "Teams", URL: url, payload: func(text string, n Notification) any {
+		return map[string]any{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    fmt.Sprintf("Spot interruption: %s", n.Type),
+			"themeColor": lo.Ternary(n.Type == itn.EventError, "D13438", "0078D7"),
+			"text":       text,
+		}
+	}}
+}
+
+type Options struct {
+	// Template is the text/template of the messages, executed with a Notification. DefaultTemplate is used if
+	// it's empty.
+	Template string
+	// Events are the types of the events notified. DefaultEvents are notified if it's empty.
+	Events []itn.EventType
+	// Attempts is how many times a notification is posted before giving up, at least once
+	Attempts int
+}
+
+// Notifier posts the events of interruptions to sinks like Slack
+type Notifier struct {
+	sinks    []Sink
+	template *template.Template
+	events   []itn.EventType
+	attempts int
+	client   *http.Client
+}
+
+func New(sinks []Sink, opts Options) (*Notifier, error) {
+	tmpl, err := template.New("notification").Option("missingkey=error").Parse(lo.CoalesceOrEmpty(opts.Template, DefaultTemplate))
+	if err != nil {
+		return nil, err
+	}
+	// catch fields that don't exist before the first event
+	sample := Notification{Event: itn.Event{Timestamp: time.Now()}, ExperimentIDs: []string{"EXP1"}}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, err
+	}
+	return &Notifier{
+		sinks:    sinks,
+		template: tmpl,
+		events:   lo.Ternary(len(opts.Events) == 0, DefaultEvents, opts.Events),
+		attempts: max(opts.Attempts, 1),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Notify renders the notification and posts it to every sink, retrying with backoff
+func (n *Notifier) Notify(ctx context.Context, notification Notification) error {
+	var text strings.Builder
+	if err := n.template.Execute(&text, notification); err != nil {
+		return err
+	}
+	var errs []error
+	for _, sink := range n.sinks {
+		if err := n.post(ctx, sink, sink.payload(text.String(), notification)); err != nil {
+			errs = append(errs, fmt.Errorf("notifying %s: %w", sink.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post posts the payload to the sink, retrying connection failures, throttling and server errors
+func (n *Notifier) post(ctx context.Context, sink Sink, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	wait := backoff
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := n.send(ctx, sink.URL, body)
+		if err == nil {
+			return nil
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt >= n.attempts {
+			return err
+		}
+		select {
+		case <-time.After(max(wait, retryAfter)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+// permanentError is a response that retrying won't change, like a revoked webhook
+type permanentError struct{ error }
+
+// send posts the body, returning how long the sink asked to wait before retrying if it was throttled
+func (n *Notifier) send(ctx context.Context, url string, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return time.Duration(seconds) * time.Second, fmt.Errorf("%s", resp.Status)
+	default:
+		return 0, permanentError{fmt.Errorf("%s", resp.Status)}
+	}
+}
+
+// Watcher notifies the events as they pass through, in order and without holding them up. Notifications that
+// still fail after retrying are reported once the events are done, without failing the run.
+func (n *Notifier) Watcher() itn.Watcher {
+	return func(runs []itn.Run, events <-chan itn.Event) <-chan itn.Event {
+		out := make(chan itn.Event, 10)
+		queue := make(chan itn.Event, 100)
+		experimentIDs := lo.FilterMap(runs, func(run itn.Run, _ int) (string, bool) {
+			return lo.FromPtr(lo.FromPtr(run.Experiment).Id), run.Experiment != nil
+		})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var failures []error
+			for event := range queue {
+				if err := n.Notify(context.Background(), Notification{Event: event, ExperimentIDs: experimentIDs}); err != nil {
+					failures = append(failures, err)
+				}
+			}
+			for _, err := range failures {
+				out <- itn.Event{Type: EventNotificationFailed, Message: fmt.Sprintf("⚠️ %s", err), Timestamp: time.Now()}
+			}
+		}()
+		go func() {
+			defer close(out)
+			for event := range events {
+				if lo.Contains(n.events, event.Type) {
+					queue <- event
+				}
+				out <- event
+			}
+			close(queue)
+			wg.Wait()
+		}()
+		return out
+	}
+}
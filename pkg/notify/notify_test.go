@@ -0,0 +1,177 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+)
+
+func TestMain(m *testing.M) {
+	backoff = time.Millisecond
+	m.Run()
+}
+
+func TestSinks(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	notifier, err := New([]Sink{Webhook(server.URL + "/webhook"), Slack(server.URL + "/slack"), Teams(server.URL + "/teams")}, Options{})
+	h.Ok(t, err)
+	err = notifier.Notify(context.Background(), Notification{
+		Event:         itn.Event{Type: itn.EventShutdown, Message: "✅ Spot Instance Shutdown sent", Region: "us-west-2"},
+		ExperimentIDs: []string{"EXP1"},
+	})
+	h.Ok(t, err)
+
+	webhook := server.received("/webhook")[0]
+	h.Equals(t, "Shutdown", webhook["type"])
+	h.Equals(t, "[us-west-2] ✅ Spot Instance Shutdown sent", webhook["text"])
+	h.Equals(t, []any{"EXP1"}, webhook["experimentIds"])
+	h.Equals(t, map[string]any{"text": "[us-west-2] ✅ Spot Instance Shutdown sent"}, server.received("/slack")[0])
+	teams := server.received("/teams")[0]
+	h.Equals(t, "MessageCard", teams["@type"])
+	h.Equals(t, "[us-west-2] ✅ Spot Instance Shutdown sent", teams["text"])
+}
+
+func TestTemplate(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	notifier, err := New([]Sink{Slack(server.URL + "/slack")}, Options{Template: `GameDay {{join .ExperimentIDs ","}}: {{.Type}} {{.Message}}`})
+	h.Nok(t, err)
+
+	notifier, err = New([]Sink{Slack(server.URL + "/slack")}, Options{Template: `GameDay {{index .ExperimentIDs 0}}: {{.Type}} at {{.Timestamp.Format "15:04"}}`})
+	h.Ok(t, err)
+	_, err = New(nil, Options{Template: "{{.Missing}}"})
+	h.Nok(t, err)
+	_, err = New(nil, Options{Template: "{{.Message"})
+	h.Nok(t, err)
+
+	err = notifier.Notify(context.Background(), Notification{
+		Event:         itn.Event{Type: itn.EventInterruptionNotification, Timestamp: time.Date(2024, 5, 18, 11, 40, 0, 0, time.UTC)},
+		ExperimentIDs: []string{"EXP1"},
+	})
+	h.Ok(t, err)
+	h.Equals(t, "GameDay EXP1: InterruptionNotification at 11:40", server.received("/slack")[0]["text"])
+}
+
+func TestRetry(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.failures["/slack"] = []int{http.StatusTooManyRequests, http.StatusBadGateway}
+	server.failures["/revoked"] = []int{http.StatusNotFound}
+	notifier, err := New([]Sink{Slack(server.URL + "/slack")}, Options{Attempts: 3})
+	h.Ok(t, err)
+	h.Ok(t, notifier.Notify(context.Background(), Notification{Event: itn.Event{Message: "✅ Rebalance Recommendation sent"}}))
+	h.Equals(t, 3, server.attempts("/slack"))
+
+	// a revoked webhook isn't retried
+	notifier, err = New([]Sink{Slack(server.URL + "/revoked")}, Options{Attempts: 3})
+	h.Ok(t, err)
+	err = notifier.Notify(context.Background(), Notification{Event: itn.Event{Message: "✅ Rebalance Recommendation sent"}})
+	h.Nok(t, err)
+	h.Equals(t, "notifying Slack: 404 Not Found", err.Error())
+	h.Equals(t, 1, server.attempts("/revoked"))
+}
+
+func TestWatcher(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.failures["/teams"] = []int{http.StatusInternalServerError, http.StatusInternalServerError}
+	notifier, err := New([]Sink{Slack(server.URL + "/slack"), Teams(server.URL + "/teams")}, Options{Attempts: 2})
+	h.Ok(t, err)
+	runs := []itn.Run{{Region: "us-west-2", Experiment: &types.Experiment{Id: aws.String("EXP1")}}}
+	events := make(chan itn.Event, 10)
+	sent := []itn.Event{
+		{Type: itn.EventRebalanceRecommendation, Message: "✅ Rebalance Recommendation sent"},
+		{Type: itn.EventInterruptionScheduled, Message: "⏳ Interruption will be sent in 15 seconds"},
+		{Type: itn.EventInterruptionNotification, Message: "✅ Spot 2-minute Interruption Notification sent"},
+		{Type: itn.EventShutdown, Message: "✅ Spot Instance Shutdown sent"},
+	}
+	for _, event := range sent {
+		events <- event
+	}
+	close(events)
+
+	var received []itn.Event
+	for event := range itn.Watch(runs, events, notifier.Watcher()) {
+		received = append(received, event)
+	}
+	// every event passes through, followed by the notification that failed
+	h.Equals(t, 5, len(received))
+	h.Equals(t, sent, received[:4])
+	h.Equals(t, EventNotificationFailed, received[4].Type)
+	h.Assert(t, strings.Contains(received[4].Message, "notifying Teams: 500 Internal Server Error"), "expected the Teams failure, got %q", received[4].Message)
+
+	// the scheduling isn't one of the default events, and the rest are notified in order
+	texts := []any{}
+	for _, body := range server.received("/slack") {
+		texts = append(texts, body["text"])
+	}
+	h.Equals(t, []any{"✅ Rebalance Recommendation sent", "✅ Spot 2-minute Interruption Notification sent", "✅ Spot Instance Shutdown sent"}, texts)
+}
+
+// Mocks
+
+// server records the JSON posted to each path, failing with the statuses queued for the path first
+type server struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   map[string][]map[string]any
+	calls    map[string]int
+	failures map[string][]int
+}
+
+func newServer() *server {
+	s := &server{bodies: map[string][]map[string]any{}, calls: map[string]int{}, failures: map[string][]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.calls[r.URL.Path]++
+		if failures := s.failures[r.URL.Path]; len(failures) > 0 {
+			s.failures[r.URL.Path] = failures[1:]
+			w.WriteHeader(failures[0])
+			return
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.bodies[r.URL.Path] = append(s.bodies[r.URL.Path], body)
+	}))
+	return s
+}
+
+func (s *server) received(path string) []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bodies[path]
+}
+
+func (s *server) attempts(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[path]
+}
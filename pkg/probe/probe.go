@@ -0,0 +1,248 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/samber/lo"
+)
+
+const EventProbeReport itn.EventType = "ProbeReport"
+
+// Phase is a window of the interruption that probes are measured in
+type Phase string
+
+const (
+	PhasePreRebalance Phase = "pre-rebalance"
+	PhaseRebalance    Phase = "rebalance→ITN"
+	PhaseInterruption Phase = "ITN→shutdown"
+	PhasePostShutdown Phase = "post-shutdown"
+)
+
+// Phases are in the order they happen
+var Phases = []Phase{PhasePreRebalance, PhaseRebalance, PhaseInterruption, PhasePostShutdown}
+
+// Check probes the health of an application, failing with an error
+type Check func(ctx context.Context) error
+
+// HTTP checks that a GET of the URL responds with the status
+func HTTP(url string, status int) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			return fmt.Errorf("expected status %d, got %d", status, resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// Command checks that the shell command exits successfully
+func Command(command string) Check {
+	return func(ctx context.Context) error {
+		return exec.CommandContext(ctx, "sh", "-c", command).Run()
+	}
+}
+
+type Options struct {
+	// Interval is how often to probe, and how long each check may take
+	Interval time.Duration
+	// Threshold is the lowest success rate of any phase that doesn't fail the run, between 0 and 1
+	Threshold float64
+	// After is how long to keep probing after the instances shut down
+	After time.Duration
+}
+
+type sample struct {
+	phase   Phase
+	ok      bool
+	latency time.Duration
+}
+
+// Probe checks the health of an application throughout an interruption
+type Probe struct {
+	Name    string
+	check   Check
+	opts    Options
+	mu      sync.Mutex
+	phase   Phase
+	samples []sample
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func New(name string, check Check, opts Options) *Probe {
+	return &Probe{Name: name, check: check, opts: opts, phase: PhasePreRebalance}
+}
+
+// Start probes in the background until the watcher is done, so there's a baseline from before the interruption starts
+func (p *Probe) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.opts.Interval)
+		defer ticker.Stop()
+		for {
+			p.probe(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (p *Probe) probe(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, p.opts.Interval)
+	defer cancel()
+	phase := p.current()
+	start := time.Now()
+	err := p.check(checkCtx)
+	if ctx.Err() != nil {
+		// stopped mid-check
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.samples = append(p.samples, sample{phase: phase, ok: err == nil, latency: time.Since(start)})
+}
+
+// Watcher moves the probe through the phases of the interruption as the events arrive. Once the instances have shut
+// down and it has probed for the After duration, or the context is done, it stops the probe and reports the success
+// rate and latency percentiles of each phase, failing the run if a phase's success rate is under the Threshold.
+func (p *Probe) Watcher(ctx context.Context) itn.Watcher {
+	return func(_ []itn.Run, events <-chan itn.Event) <-chan itn.Event {
+		out := make(chan itn.Event, 10)
+		go func() {
+			defer close(out)
+			for event := range events {
+				switch event.Type {
+				case itn.EventRebalanceRecommendation:
+					p.enter(PhaseRebalance)
+				case itn.EventInterruptionNotification:
+					p.enter(PhaseInterruption)
+				case itn.EventShutdown:
+					p.enter(PhasePostShutdown)
+				}
+				out <- event
+			}
+			if p.current() == PhasePostShutdown {
+				select {
+				case <-time.After(p.opts.After):
+				case <-ctx.Done():
+				}
+			}
+			// there's nothing to stop if the probe was never started
+			if p.cancel != nil {
+				p.cancel()
+				<-p.done
+			}
+			for _, stats := range p.Stats() {
+				out <- itn.Event{Type: EventProbeReport, Message: fmt.Sprintf("📋 Probe %s %s", p.Name, stats), Timestamp: time.Now()}
+				if stats.SuccessRate() < p.opts.Threshold {
+					out <- itn.Event{
+						Type:      itn.EventError,
+						Message:   fmt.Sprintf("❌ Probe %s succeeded %.1f%% of the time %s, under the %.1f%% threshold", p.Name, stats.SuccessRate()*100, stats.Phase, p.opts.Threshold*100),
+						Timestamp: time.Now(),
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// enter moves the probe forward to the phase, ignoring events that arrive out of order
+func (p *Probe) enter(phase Phase) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if lo.IndexOf(Phases, phase) > lo.IndexOf(Phases, p.phase) {
+		p.phase = phase
+	}
+}
+
+func (p *Probe) current() Phase {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.phase
+}
+
+// Stats are the results of the probes in a phase
+type Stats struct {
+	Phase     Phase
+	Probes    int
+	Successes int
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+}
+
+func (s Stats) SuccessRate() float64 {
+	if s.Probes == 0 {
+		return 1
+	}
+	return float64(s.Successes) / float64(s.Probes)
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%s: %.1f%% of %d succeeded, p50 %s, p90 %s, p99 %s", s.Phase, s.SuccessRate()*100, s.Probes,
+		s.P50.Round(time.Millisecond), s.P90.Round(time.Millisecond), s.P99.Round(time.Millisecond))
+}
+
+// Stats returns the results of the phases that were probed, in order
+func (p *Probe) Stats() []Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var stats []Stats
+	for _, phase := range Phases {
+		samples := lo.Filter(p.samples, func(s sample, _ int) bool { return s.phase == phase })
+		if len(samples) == 0 {
+			continue
+		}
+		latencies := lo.Map(samples, func(s sample, _ int) time.Duration { return s.latency })
+		sort.Slice(latencies, func(a, b int) bool { return latencies[a] < latencies[b] })
+		stats = append(stats, Stats{
+			Phase:     phase,
+			Probes:    len(samples),
+			Successes: lo.CountBy(samples, func(s sample) bool { return s.ok }),
+			P50:       percentile(latencies, 50),
+			P90:       percentile(latencies, 90),
+			P99:       percentile(latencies, 99),
+		})
+	}
+	return stats
+}
+
+// percentile is the nearest-rank percentile of the sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,109 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package probe
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+)
+
+func TestHTTP(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unhealthy" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	h.Ok(t, HTTP(server.URL+"/healthy", http.StatusOK)(ctx))
+	h.Nok(t, HTTP(server.URL+"/unhealthy", http.StatusOK)(ctx))
+	h.Ok(t, HTTP(server.URL+"/unhealthy", http.StatusServiceUnavailable)(ctx))
+}
+
+func TestCommand(t *testing.T) {
+	ctx := context.Background()
+	h.Ok(t, Command("exit 0")(ctx))
+	h.Nok(t, Command("exit 1")(ctx))
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	h.Equals(t, 50*time.Millisecond, percentile(latencies, 50))
+	h.Equals(t, 99*time.Millisecond, percentile(latencies, 99))
+	h.Equals(t, 5*time.Millisecond, percentile(latencies[4:5], 50))
+}
+
+func TestWatcher(t *testing.T) {
+	ctx := context.Background()
+	// the application is unhealthy from the interruption notification until the instances shut down
+	var unhealthy atomic.Bool
+	probe := New("app", func(context.Context) error {
+		if unhealthy.Load() {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}, Options{Interval: 5 * time.Millisecond, Threshold: 0.5, After: 50 * time.Millisecond})
+	probe.Start(ctx)
+	events := make(chan itn.Event)
+	out := probe.Watcher(ctx)(nil, events)
+	go func() {
+		defer close(events)
+		for _, eventType := range []itn.EventType{itn.EventRebalanceRecommendation, itn.EventInterruptionNotification, itn.EventShutdown} {
+			time.Sleep(50 * time.Millisecond)
+			events <- itn.Event{Type: eventType, Timestamp: time.Now()}
+			unhealthy.Store(eventType == itn.EventInterruptionNotification)
+		}
+	}()
+
+	var reports, errs []string
+	for event := range out {
+		switch event.Type {
+		case EventProbeReport:
+			reports = append(reports, event.Message)
+		case itn.EventError:
+			errs = append(errs, event.Message)
+		}
+	}
+	h.Equals(t, 4, len(reports))
+	for i, phase := range Phases {
+		h.Assert(t, strings.HasPrefix(reports[i], "📋 Probe app "+string(phase)+": "), "unexpected report %q", reports[i])
+	}
+	h.Equals(t, 1, len(errs))
+	h.Assert(t, strings.HasPrefix(errs[0], "❌ Probe app succeeded") && strings.Contains(errs[0], "of the time ITN→shutdown"), "unexpected error %q", errs[0])
+
+	// a probe that was never started has nothing to report, and cancelling stops waiting after the shutdown
+	ctx, cancel := context.WithCancel(ctx)
+	probe = New("app", func(context.Context) error { return nil }, Options{Interval: time.Minute, After: time.Hour})
+	events = make(chan itn.Event, 1)
+	events <- itn.Event{Type: itn.EventShutdown, Timestamp: time.Now()}
+	close(events)
+	out = probe.Watcher(ctx)(nil, events)
+	h.Equals(t, itn.EventShutdown, (<-out).Type)
+	cancel()
+	_, ok := <-out
+	h.Assert(t, !ok, "expected the watcher to be done")
+}
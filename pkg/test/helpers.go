@@ -14,13 +14,17 @@
 package test
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"testing"
 )
 
+var update = flag.Bool("update", false, "update the golden files")
+
 // Assert fails the test if the condition is false.
 func Assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
 	if !condition {
@@ -80,3 +84,27 @@ func Nok(tb testing.TB, err error) {
 		tb.FailNow()
 	}
 }
+
+// Golden fails the test if act doesn't match the contents of testdata/<name>.golden. The golden files are written
+// from act instead when the tests are run with -update.
+func Golden(tb testing.TB, name string, act string) {
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(act), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+	exp, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("reading %s, run the tests with -update to create it: %s", path, err)
+	}
+	if string(exp) != act {
+		_, file, line, _ := runtime.Caller(1)
+		fmt.Printf("\033[31m%s:%d: %s doesn't match, run the tests with -update if the change is expected\n\n\texp:\n%s\n\n\tgot:\n%s\033[39m\n\n", filepath.Base(file), line, path, exp, act)
+		tb.FailNow()
+	}
+}
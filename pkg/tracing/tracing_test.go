@@ -0,0 +1,95 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStart(t *testing.T) {
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+	collector := newCollector()
+	defer collector.Close()
+	ctx := context.Background()
+	shutdown, err := Start(ctx, collector.URL, "v1.2.3")
+	h.Ok(t, err)
+
+	ctx, root := otel.Tracer("test").Start(ctx, "Interrupt")
+	_, child := otel.Tracer("test").Start(ctx, "validate")
+	child.End()
+	root.End()
+	h.Ok(t, shutdown(context.Background()))
+
+	h.Equals(t, 1, len(collector.requests))
+	resourceSpans := collector.requests[0].ResourceSpans[0]
+	attributes := map[string]string{}
+	for _, attribute := range resourceSpans.Resource.Attributes {
+		attributes[attribute.Key] = attribute.Value.GetStringValue()
+	}
+	h.Equals(t, "ec2-spot-interrupter", attributes["service.name"])
+	h.Equals(t, "v1.2.3", attributes["service.version"])
+	spans := resourceSpans.ScopeSpans[0].Spans
+	h.Equals(t, 2, len(spans))
+	h.Equals(t, "validate", spans[0].Name)
+	h.Equals(t, "Interrupt", spans[1].Name)
+	h.Equals(t, spans[1].SpanId, spans[0].ParentSpanId)
+}
+
+func TestStartInvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"localhost:4318", "grpc://localhost:4317", "http://"} {
+		_, err := Start(context.Background(), endpoint, "v1.2.3")
+		h.Nok(t, err)
+	}
+}
+
+// collector is a local OTLP/HTTP collector recording the traces it receives
+type collector struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*collectortrace.ExportTraceServiceRequest
+}
+
+func newCollector() *collector {
+	c := &collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != tracesPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		request := &collectortrace.ExportTraceServiceRequest{}
+		if err != nil || proto.Unmarshal(body, request) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		c.requests = append(c.requests, request)
+		c.mu.Unlock()
+		response, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(response)
+	}))
+	return c
+}
@@ -0,0 +1,40 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+)
+
+// Backend is what the TUI needs from an *itn.ITN. It lets the models be driven by a fake in tests.
+type Backend interface {
+	Region() string
+	Profile() string
+	AccountID(ctx context.Context) (string, error)
+	SpotInstances(ctx context.Context) ([]ec2types.Instance, error)
+	SpotInstanceRequest(ctx context.Context, instance ec2types.Instance) (*ec2types.SpotInstanceRequest, error)
+	InstanceStates(ctx context.Context, instanceIDs []string) (map[string]string, error)
+	CheckGuardrails(ctx context.Context, instanceIDs []string, guardrails itn.Guardrails) error
+	Interrupt(ctx context.Context, instanceIDs []string, opts itn.Options) (*types.Experiment, <-chan itn.Event, error)
+	Attach(ctx context.Context, experimentID string) (*types.Experiment, <-chan itn.Event, error)
+	Experiments(ctx context.Context, status types.ExperimentStatus) ([]itn.Listing, error)
+	LeftoverTemplates(ctx context.Context) ([]types.ExperimentTemplateSummary, error)
+	Stop(ctx context.Context, experimentID string) error
+	DeleteTemplate(ctx context.Context, templateID string) error
+	EventBridgeWatcher(ctx context.Context, eventBus string) itn.Watcher
+}
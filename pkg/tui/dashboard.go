@@ -0,0 +1,280 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+)
+
+// recentWindow is how long finished experiments are listed on the dashboard for
+const recentWindow = 24 * time.Hour
+
+var (
+	activeTabStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("206")).Underline(true)
+	inactiveTabStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// renderTabs renders the tabs of the interactive mode, switched between with tab
+func renderTabs(active int) string {
+	tabs := lo.Map([]string{"Instances", "Experiments"}, func(title string, i int) string {
+		return lo.Ternary(i == active, activeTabStyle, inactiveTabStyle).Render(title)
+	})
+	return strings.Join(tabs, "  │  ") + "\n\n"
+}
+
+// dashboard lists the running and recent Spot ITN experiments and the leftover templates, which can be attached
+// to, stopped and deleted
+type dashboard struct {
+	ctx       context.Context
+	itn       Backend
+	selection model
+	listings  []itn.Listing
+	templates []types.ExperimentTemplateSummary
+	cursor    int
+	loaded    bool
+	// confirming is the action waiting for confirmation, if any
+	confirming string
+	spinner    spinner.Model
+}
+
+type dashboardMsg struct {
+	listings  []itn.Listing
+	templates []types.ExperimentTemplateSummary
+}
+type attachedMsg struct {
+	experiment *types.Experiment
+	events     <-chan itn.Event
+	cancel     context.CancelFunc
+}
+
+func newDashboard(selection model) dashboard {
+	return dashboard{ctx: selection.ctx, itn: selection.itn, selection: selection, spinner: selection.spinner}
+}
+
+func (d dashboard) Init() tea.Cmd {
+	return tea.Batch(spinner.Tick, d.load())
+}
+
+func (d dashboard) load() tea.Cmd {
+	return func() tea.Msg {
+		listings, err := d.itn.Experiments(d.ctx, "")
+		if err != nil {
+			return errMsg{err: err}
+		}
+		templates, err := d.itn.LeftoverTemplates(d.ctx)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		listings = lo.Filter(listings, func(listing itn.Listing, _ int) bool {
+			return listing.Active() || time.Since(lo.FromPtr(listing.Experiment.CreationTime)) < recentWindow
+		})
+		return dashboardMsg{listings: listings, templates: templates}
+	}
+}
+
+// action runs a change to an experiment or template, reloading the dashboard once it's done
+func (d dashboard) action(title string, run func() error) tea.Cmd {
+	return func() tea.Msg {
+		if err := run(); err != nil {
+			return actionErrMsg{title: title, err: err}
+		}
+		return d.load()()
+	}
+}
+
+type actionErrMsg struct {
+	title string
+	err   error
+}
+
+func (d dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case dashboardMsg:
+		d.listings, d.templates = msg.listings, msg.templates
+		d.loaded = true
+		d.cursor = max(min(d.cursor, len(d.listings)+len(d.templates)-1), 0)
+	case errMsg:
+		return errorView{
+			title: "Listing the experiments failed",
+			err:   msg.err,
+			retry: func() (tea.Model, tea.Cmd) { return d, d.load() },
+			back:  d.selection,
+		}, nil
+	case actionErrMsg:
+		return errorView{
+			title: msg.title,
+			err:   msg.err,
+			retry: func() (tea.Model, tea.Cmd) { return d, d.load() },
+			back:  d,
+		}, nil
+	case attachedMsg:
+		run := itn.Run{Region: d.itn.Region(), Experiment: msg.experiment, InstanceIDs: itn.ExperimentInstanceIDs(*msg.experiment)}
+		monitor := NewMonitor(d.ctx, d.itn, run, msg.events)
+		monitor.back, monitor.detach = d, msg.cancel
+		return monitor, monitor.Init()
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		d.spinner, cmd = d.spinner.Update(msg)
+		return d, cmd
+	case tea.KeyMsg:
+		if d.confirming != "" {
+			return d.confirm(msg)
+		}
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return d, tea.Quit
+		case "tab":
+			return d.selection, nil
+		case "up", "k":
+			d.cursor = max(d.cursor-1, 0)
+		case "down", "j":
+			d.cursor = max(min(d.cursor+1, len(d.listings)+len(d.templates)-1), 0)
+		case "r":
+			d.loaded = false
+			return d, d.load()
+		case "enter":
+			if listing, ok := d.listing(); ok {
+				return d, d.attach(*listing.Experiment.Id)
+			}
+		case "s":
+			if listing, ok := d.listing(); ok && listing.Active() {
+				d.confirming = "s"
+			}
+		case "x":
+			if _, ok := d.template(); ok {
+				d.confirming = "x"
+			}
+		}
+	}
+	return d, nil
+}
+
+// confirm runs the action waiting for confirmation if y is pressed, and cancels it otherwise
+func (d dashboard) confirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	action := d.confirming
+	d.confirming = ""
+	if msg.String() != "y" {
+		return d, nil
+	}
+	if listing, ok := d.listing(); ok && action == "s" {
+		id := *listing.Experiment.Id
+		return d, d.action(fmt.Sprintf("Stopping %s failed", id), func() error { return d.itn.Stop(d.ctx, id) })
+	}
+	if template, ok := d.template(); ok && action == "x" {
+		id := *template.Id
+		return d, d.action(fmt.Sprintf("Deleting %s failed", id), func() error { return d.itn.DeleteTemplate(d.ctx, id) })
+	}
+	return d, nil
+}
+
+func (d dashboard) attach(experimentID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithCancel(d.ctx)
+		experiment, events, err := d.itn.Attach(ctx, experimentID)
+		if err != nil {
+			cancel()
+			return actionErrMsg{title: fmt.Sprintf("Attaching to %s failed", experimentID), err: err}
+		}
+		return attachedMsg{experiment: experiment, events: events, cancel: cancel}
+	}
+}
+
+// listing returns the experiment under the cursor, if it's on one
+func (d dashboard) listing() (itn.Listing, bool) {
+	if d.cursor < len(d.listings) {
+		return d.listings[d.cursor], true
+	}
+	return itn.Listing{}, false
+}
+
+// template returns the leftover template under the cursor, if it's on one
+func (d dashboard) template() (types.ExperimentTemplateSummary, bool) {
+	if i := d.cursor - len(d.listings); i >= 0 && i < len(d.templates) {
+		return d.templates[i], true
+	}
+	return types.ExperimentTemplateSummary{}, false
+}
+
+func (d dashboard) View() string {
+	s := renderTabs(1)
+	if !d.loaded {
+		return s + fmt.Sprintf("Listing the experiments %s\n%s", d.spinner.View(), help())
+	}
+	s += headerStyle.Render("Running and recent Spot ITN experiments") + "\n"
+	if len(d.listings) == 0 {
+		s += "None in the last day.\n"
+	}
+	var experiments strings.Builder
+	w := tabwriter.NewWriter(&experiments, 0, 0, 2, ' ', 0)
+	for i, listing := range d.listings {
+		created := "-"
+		if listing.Experiment.CreationTime != nil {
+			created = listing.Experiment.CreationTime.Format("2006-01-02T15:04:05")
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%d instances\t%s\n", lo.Ternary(i == d.cursor, ">", " "), *listing.Experiment.Id,
+			lo.FromPtr(listing.Experiment.State).Status, created, len(listing.InstanceIDs()), lo.Ternary(listing.StartedBy == "", "-", listing.StartedBy))
+	}
+	w.Flush()
+	s += d.highlight(experiments.String(), 0)
+
+	s += "\n" + headerStyle.Render("Leftover experiment templates") + "\n"
+	if len(d.templates) == 0 {
+		s += "None.\n"
+	}
+	var templates strings.Builder
+	w = tabwriter.NewWriter(&templates, 0, 0, 2, ' ', 0)
+	for i, template := range d.templates {
+		created := "-"
+		if template.CreationTime != nil {
+			created = template.CreationTime.Format("2006-01-02T15:04:05")
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\n", lo.Ternary(len(d.listings)+i == d.cursor, ">", " "), *template.Id, created, lo.FromPtr(template.Description))
+	}
+	w.Flush()
+	s += d.highlight(templates.String(), len(d.listings))
+
+	switch d.confirming {
+	case "s":
+		s += errorStyle.Render(fmt.Sprintf("\nStop %s? The instances already sent an ITN are still interrupted. (y/n)", *d.listings[d.cursor].Experiment.Id)) + "\n"
+	case "x":
+		template, _ := d.template()
+		s += errorStyle.Render(fmt.Sprintf("\nDelete the template %s? (y/n)", *template.Id)) + "\n"
+	}
+	return s + helpStyle("\n↑/↓ move • enter attach • s stop • x delete template • r refresh • tab instances • q quit\n")
+}
+
+// highlight styles the line of the rendered rows that's under the cursor, the rows starting at index first
+func (d dashboard) highlight(rendered string, first int) string {
+	lines := strings.Split(strings.TrimSuffix(rendered, "\n"), "\n")
+	for i := range lines {
+		if first+i == d.cursor {
+			lines[i] = cursorStyle.Render(lines[i])
+		}
+	}
+	if rendered == "" {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,99 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func listing(id string, status types.ExperimentStatus) itn.Listing {
+	return itn.Listing{Experiment: types.Experiment{
+		Id:           aws.String(id),
+		State:        &types.ExperimentState{Status: status},
+		CreationTime: aws.Time(time.Now()),
+	}, StartedBy: "arn:aws:iam::12345:user/alice"}
+}
+
+func TestDashboard(t *testing.T) {
+	selection := NewModel(context.Background(), itn.New(aws.Config{Region: "us-west-2"}), itn.Options{}, itn.Guardrails{})
+	var m tea.Model = selection
+	m, _ = m.Update(key("tab"))
+	_, ok := m.(dashboard)
+	h.Assert(t, ok, "expected the dashboard, got %T", m)
+	m, _ = m.Update(dashboardMsg{
+		listings:  []itn.Listing{listing("EXP1", types.ExperimentStatusRunning), listing("EXP2", types.ExperimentStatusCompleted)},
+		templates: []types.ExperimentTemplateSummary{{Id: aws.String("TPL1"), Description: aws.String("trigger spot ITN for instances [i-1]")}},
+	})
+	view := m.View()
+	for _, expected := range []string{"EXP1", "running", "arn:aws:iam::12345:user/alice", "TPL1", "trigger spot ITN for instances [i-1]"} {
+		h.Assert(t, strings.Contains(view, expected), "expected %q in the dashboard, got %q", expected, view)
+	}
+
+	// stopping asks for confirmation, and only running experiments can be stopped
+	m, _ = m.Update(key("s"))
+	h.Assert(t, strings.Contains(m.View(), "Stop EXP1?"), "expected a confirmation, got %q", m.View())
+	m, cmd := m.Update(key("n"))
+	h.Assert(t, cmd == nil && !strings.Contains(m.View(), "Stop EXP1?"), "expected the stop to be cancelled")
+	m, _ = m.Update(key("j"))
+	m, _ = m.Update(key("s"))
+	h.Equals(t, "", m.(dashboard).confirming)
+
+	// deleting a leftover template
+	m, _ = m.Update(key("j"))
+	m, _ = m.Update(key("x"))
+	h.Assert(t, strings.Contains(m.View(), "Delete the template TPL1?"), "expected a confirmation, got %q", m.View())
+	m, cmd = m.Update(key("y"))
+	h.Assert(t, cmd != nil, "expected the template to be deleted")
+
+	m, _ = m.Update(key("tab"))
+	_, ok = m.(model)
+	h.Assert(t, ok, "expected the instances, got %T", m)
+}
+
+func TestDashboardAttach(t *testing.T) {
+	d := newDashboard(NewModel(context.Background(), itn.New(aws.Config{Region: "us-west-2"}), itn.Options{}, itn.Guardrails{}))
+	events := make(chan itn.Event)
+	detached := false
+	var m tea.Model = d
+	m, _ = m.Update(attachedMsg{
+		experiment: &types.Experiment{
+			Id:      aws.String("EXP1"),
+			RoleArn: aws.String("arn:aws:iam::12345:role/aws-fis-itn"),
+			Targets: map[string]types.ExperimentTarget{"itn0": {ResourceArns: []string{"arn:aws:ec2:us-west-2:12345:instance/i-1"}}},
+		},
+		events: events,
+		cancel: func() { detached = true },
+	})
+	attached, ok := m.(monitor)
+	h.Assert(t, ok, "expected the monitor, got %T", m)
+	h.Equals(t, []string{"i-1"}, attached.instanceIDs)
+	h.Assert(t, strings.Contains(m.View(), "Press b to go back"), "expected to be able to go back, got %q", m.View())
+
+	// the monitor stays open once the experiment is done, to go back to the dashboard
+	m, _ = m.Update(doneMsg(true))
+	m, _ = m.Update(key("b"))
+	_, ok = m.(dashboard)
+	h.Assert(t, ok, "expected the dashboard, got %T", m)
+	h.Assert(t, detached, "expected the experiment to stop being followed")
+	close(events)
+}
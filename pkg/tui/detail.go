@@ -0,0 +1,120 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+)
+
+var detailStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("99")).Padding(0, 1)
+
+// spotRequest is the Spot request of an instance as far as it's been looked up
+type spotRequest struct {
+	request *ec2types.SpotInstanceRequest
+	err     error
+}
+
+type spotRequestMsg struct {
+	instanceID string
+	spotRequest
+}
+
+func fetchSpotRequest(ctx context.Context, i Backend, instance ec2types.Instance) tea.Cmd {
+	return func() tea.Msg {
+		request, err := i.SpotInstanceRequest(ctx, instance)
+		return spotRequestMsg{instanceID: *instance.InstanceId, spotRequest: spotRequest{request: request, err: err}}
+	}
+}
+
+// renderDetails renders the metadata of the instance and its Spot request, which is nil while it's looked up
+func renderDetails(instance ec2types.Instance, request *spotRequest) string {
+	var b strings.Builder
+	field := func(name string, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-22s %s\n", name+":", value)
+		}
+	}
+	b.WriteString(headerStyle.Render(*instance.InstanceId) + "\n")
+	field("Name", instanceName(instance))
+	field("Type", string(instance.InstanceType))
+	if instance.State != nil {
+		field("State", string(instance.State.Name))
+	}
+	if instance.Placement != nil {
+		field("Availability Zone", lo.FromPtr(instance.Placement.AvailabilityZone))
+	}
+	field("VPC", lo.FromPtr(instance.VpcId))
+	field("Subnet", lo.FromPtr(instance.SubnetId))
+	field("Private IP", lo.FromPtr(instance.PrivateIpAddress))
+	field("Public IP", lo.FromPtr(instance.PublicIpAddress))
+	field("AMI", lo.FromPtr(instance.ImageId))
+	field("Architecture", string(instance.Architecture))
+	field("Key pair", lo.FromPtr(instance.KeyName))
+	if instance.IamInstanceProfile != nil {
+		field("Instance profile", lo.FromPtr(instance.IamInstanceProfile.Arn))
+	}
+	field("Security groups", strings.Join(lo.Map(instance.SecurityGroups, func(group ec2types.GroupIdentifier, _ int) string {
+		return fmt.Sprintf("%s (%s)", lo.FromPtr(group.GroupName), lo.FromPtr(group.GroupId))
+	}), ", "))
+	if instance.LaunchTime != nil {
+		field("Launched", instance.LaunchTime.Format(time.RFC3339))
+	}
+	field("ASG", tagValue(instance, "aws:autoscaling:groupName"))
+	tags := lo.Map(instance.Tags, func(tag ec2types.Tag, _ int) string {
+		return fmt.Sprintf("%s=%s", lo.FromPtr(tag.Key), lo.FromPtr(tag.Value))
+	})
+	sort.Strings(tags)
+	if len(tags) > 0 {
+		b.WriteString("Tags:\n")
+		for _, tag := range tags {
+			fmt.Fprintf(&b, "  %s\n", tag)
+		}
+	}
+
+	b.WriteString("\n" + headerStyle.Render("Spot request") + "\n")
+	switch {
+	case request == nil:
+		b.WriteString("Looking up the Spot request...\n")
+	case request.err != nil:
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Looking up the Spot request failed: %s", request.err)) + "\n")
+	case request.request == nil:
+		b.WriteString("The instance wasn't launched by a Spot request.\n")
+	default:
+		r := request.request
+		field("ID", lo.FromPtr(r.SpotInstanceRequestId))
+		field("Type", string(r.Type))
+		field("State", string(r.State))
+		if r.Status != nil {
+			field("Status", fmt.Sprintf("%s: %s", lo.FromPtr(r.Status.Code), lo.FromPtr(r.Status.Message)))
+		}
+		field("Max price", lo.FromPtr(r.SpotPrice))
+		field("Interruption behavior", string(r.InstanceInterruptionBehavior))
+		if r.CreateTime != nil {
+			field("Created", r.CreateTime.Format(time.RFC3339))
+		}
+		if r.ValidUntil != nil {
+			field("Valid until", r.ValidUntil.Format(time.RFC3339))
+		}
+	}
+	return detailStyle.Render(strings.TrimRight(b.String(), "\n"))
+}
@@ -0,0 +1,107 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDetailPane(t *testing.T) {
+	var m tea.Model = NewModel(context.Background(), &fakeBackend{}, itn.Options{}, itn.Guardrails{})
+	m, _ = m.Update(spotInstancesMsg(testInstances()))
+	m, _ = m.Update(key("d"))
+	view := m.View()
+	for _, expected := range []string{"Type:", "m5.large", "team=checkout", "Looking up the Spot request..."} {
+		h.Assert(t, strings.Contains(view, expected), "expected %q in the details, got %q", expected, view)
+	}
+
+	m, _ = m.Update(spotRequestMsg{instanceID: "i-0a", spotRequest: spotRequest{request: &ec2types.SpotInstanceRequest{
+		SpotInstanceRequestId: aws.String("sir-0a"),
+		State:                 ec2types.SpotInstanceStateActive,
+		Status:                &ec2types.SpotInstanceStatus{Code: aws.String("fulfilled"), Message: aws.String("Your spot request is fulfilled.")},
+		Type:                  ec2types.SpotInstanceTypeOneTime,
+	}}})
+	view = m.View()
+	for _, expected := range []string{"sir-0a", "one-time", "fulfilled: Your spot request is fulfilled."} {
+		h.Assert(t, strings.Contains(view, expected), "expected %q in the Spot request, got %q", expected, view)
+	}
+
+	// toggling the row again hides the details
+	m, _ = m.Update(key("d"))
+	h.Assert(t, !strings.Contains(m.View(), "sir-0a"), "expected the details to be hidden, got %q", m.View())
+}
+
+func TestSwitcher(t *testing.T) {
+	defer func(c func(context.Context, string, string) (Backend, error)) { connect = c }(connect)
+	connect = func(ctx context.Context, region string, profile string) (Backend, error) {
+		if profile == "missing" {
+			return nil, errors.New("failed to get shared config profile, missing")
+		}
+		return itn.New(aws.Config{Region: region}), nil
+	}
+	var watchedRegion string
+	watchers := func(backend Backend) ([]itn.Watcher, error) {
+		watchedRegion = backend.Region()
+		return nil, nil
+	}
+	selection := NewModel(context.Background(), itn.New(aws.Config{Region: "us-west-2"}), itn.Options{}, itn.Guardrails{}, watchers)
+	var m tea.Model = selection
+	m, _ = m.Update(spotInstancesMsg(testInstances()))
+	m, _ = m.Update(key("R"))
+	_, ok := m.(switcher)
+	h.Assert(t, ok, "expected the switcher, got %T", m)
+	h.Equals(t, "us-west-2", m.(switcher).inputs[0].Value())
+
+	// a failed switch stays on the switcher
+	for _, k := range []string{"tab", "m", "i", "s", "s", "i", "n", "g"} {
+		m, _ = m.Update(key(k))
+	}
+	m, cmd := m.Update(key("enter"))
+	m, _ = m.Update(cmd())
+	h.Assert(t, strings.Contains(m.View(), "failed to get shared config profile"), "expected the error, got %q", m.View())
+
+	for range "missing" {
+		m, _ = m.Update(key("backspace"))
+	}
+	m, _ = m.Update(key("tab"))
+	for range "us-west-2" {
+		m, _ = m.Update(key("backspace"))
+	}
+	for _, k := range "eu-west-1" {
+		m, _ = m.Update(key(string(k)))
+	}
+	m, cmd = m.Update(key("enter"))
+	m, _ = m.Update(cmd())
+	switched, ok := m.(model)
+	h.Assert(t, ok, "expected the instances to be listed again, got %T", m)
+	h.Equals(t, "eu-west-1", switched.itn.Region())
+	h.Assert(t, !switched.initialized, "expected the instances to be reloaded")
+
+	// the watchers are built from the backend switched to, like the EventBridge watcher publishing in its account
+	m, _ = m.Update(spotInstancesMsg(testInstances()))
+	m, _ = m.Update(key(" "))
+	m, _ = m.Update(key("enter"))
+	_, ok = m.(options)
+	h.Assert(t, ok, "expected the options, got %T", m)
+	h.Equals(t, "eu-west-1", watchedRegion)
+}
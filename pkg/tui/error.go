@@ -0,0 +1,87 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"errors"
+	"fmt"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+
+// errorView shows what failed along with the AWS error details, and lets the user retry or go back
+type errorView struct {
+	title string
+	err   error
+	// retry starts over what failed
+	retry func() (tea.Model, tea.Cmd)
+	// back is the screen to go back to, if any
+	back tea.Model
+}
+
+type errMsg struct{ err error }
+
+func (e errorView) Init() tea.Cmd {
+	return nil
+}
+
+func (e errorView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return e, tea.Quit
+		case "r":
+			return e.retry()
+		case "b", "esc":
+			if e.back != nil {
+				return e.back, nil
+			}
+		}
+	}
+	return e, nil
+}
+
+func (e errorView) View() string {
+	s := errorStyle.Render(fmt.Sprintf("❌ %s", e.title)) + "\n\n"
+	s += errorDetails(e.err)
+	keys := "r retry"
+	if e.back != nil {
+		keys += " • b back"
+	}
+	return s + helpStyle(fmt.Sprintf("\n%s • q quit\n", keys))
+}
+
+// errorDetails breaks AWS API errors down into their code, message and request ID, like expired credentials
+func errorDetails(err error) string {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return fmt.Sprintf("%s\n", err)
+	}
+	s := fmt.Sprintf("   Code: %s\n", apiErr.ErrorCode())
+	s += fmt.Sprintf("Message: %s\n", apiErr.ErrorMessage())
+	var opErr *smithy.OperationError
+	if errors.As(err, &opErr) {
+		s += fmt.Sprintf("   Call: %s %s\n", opErr.ServiceID, opErr.OperationName)
+	}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		s += fmt.Sprintf("Request: %s\n", respErr.ServiceRequestID())
+	}
+	return s
+}
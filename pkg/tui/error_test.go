@@ -0,0 +1,79 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"github.com/aws/smithy-go"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestErrorView(t *testing.T) {
+	err := &smithy.OperationError{
+		ServiceID:     "EC2",
+		OperationName: "DescribeInstances",
+		Err:           &smithy.GenericAPIError{Code: "ExpiredToken", Message: "The security token included in the request is expired"},
+	}
+	var m tea.Model = NewModel(context.Background(), &fakeBackend{}, itn.Options{}, itn.Guardrails{})
+	m, _ = m.Update(errMsg{err: err})
+	view := m.View()
+	for _, expected := range []string{"Finding Spot instances failed", "Code: ExpiredToken", "Message: The security token included in the request is expired", "Call: EC2 DescribeInstances"} {
+		h.Assert(t, strings.Contains(view, expected), "expected %q in the error, got %q", expected, view)
+	}
+
+	// there's nothing to go back to from finding instances, only retrying
+	m, _ = m.Update(key("b"))
+	_, ok := m.(errorView)
+	h.Assert(t, ok, "expected to stay on the error, got %T", m)
+	m, cmd := m.Update(key("r"))
+	h.Assert(t, !m.(model).initialized && cmd != nil, "expected to find the Spot instances again")
+
+	h.Equals(t, "boom\n", errorDetails(errors.New("boom")))
+}
+
+func TestMonitorStaysOpenOnFailure(t *testing.T) {
+	var m tea.Model = monitor{}
+	m, _ = m.Update(eventMsg(itn.Event{Type: itn.EventRebalanceRecommendation, Message: "✅ Rebalance Recommendation sent"}))
+	m, _ = m.Update(eventMsg(itn.Event{Type: itn.EventError, Message: "❌ Experiment failed"}))
+	m, cmd := m.Update(doneMsg(true))
+	h.Assert(t, cmd == nil, "expected the monitor to stay open")
+	h.Assert(t, strings.Contains(m.View(), "The interruption failed."), "expected the failure in %q", m.View())
+
+	m = monitor{}
+	_, cmd = m.Update(doneMsg(true))
+	h.Assert(t, cmd != nil, "expected the monitor to quit once the interruption succeeds")
+}
+
+func TestWatchersError(t *testing.T) {
+	watchers := func(backend Backend) ([]itn.Watcher, error) {
+		return nil, errors.New("template: notify:1: unexpected \"}\" in operand")
+	}
+	var m tea.Model = NewModel(context.Background(), &fakeBackend{}, itn.Options{}, itn.Guardrails{}, watchers)
+	m, _ = m.Update(spotInstancesMsg(testInstances()))
+	m, _ = m.Update(key(" "))
+	m, _ = m.Update(key("enter"))
+	_, ok := m.(errorView)
+	h.Assert(t, ok, "expected the error instead of the options, got %T", m)
+	h.Assert(t, strings.Contains(m.View(), "Setting up the watchers failed"), "expected the error in %q", m.View())
+
+	m, _ = m.Update(key("b"))
+	_, ok = m.(model)
+	h.Assert(t, ok, "expected to go back to the selection, got %T", m)
+}
@@ -0,0 +1,260 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/samber/lo"
+)
+
+// launched is when the instances of the golden tests were launched, fixed so the views don't change between runs
+var launched = time.Date(2024, 5, 18, 11, 39, 45, 0, time.UTC)
+
+func init() {
+	// render the views without colors, whether or not the tests run in a terminal
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+func goldenInstances() []ec2types.Instance {
+	web := instance("i-0a", "web", ec2types.InstanceTypeM5Large, launched, "team", "checkout")
+	web.Placement = &ec2types.Placement{AvailabilityZone: aws.String("us-west-2a")}
+	web.PrivateIpAddress = aws.String("10.0.1.10")
+	batch := instance("i-0b", "batch", ec2types.InstanceTypeC5Xlarge, launched.Add(-2*time.Hour), "team", "data", "aws:autoscaling:groupName", "batch-asg")
+	batch.Placement = &ec2types.Placement{AvailabilityZone: aws.String("us-west-2b")}
+	batch.SpotInstanceRequestId = aws.String("sir-0b")
+	return []ec2types.Instance{web, batch}
+}
+
+// drive sends the messages to the model and returns the model they lead to, dropping the commands they return
+func drive(m tea.Model, msgs ...tea.Msg) tea.Model {
+	for _, msg := range msgs {
+		m, _ = m.Update(msg)
+	}
+	return m
+}
+
+// send sends the message to the model and runs the command it returns, waiting for the messages of the wanted types
+func send(m tea.Model, msg tea.Msg, wants ...tea.Msg) tea.Model {
+	m, cmd := m.Update(msg)
+	return run(m, cmd, wants...)
+}
+
+// run runs the command and sends the model the messages it leads to of the wanted types, like the results of the
+// backend calls, returning once there's been one of each. The other messages, like ticks and blinks, are dropped.
+func run(m tea.Model, cmd tea.Cmd, wants ...tea.Msg) tea.Model {
+	msgs := make(chan tea.Msg)
+	done := make(chan struct{})
+	defer close(done)
+	var start func(cmd tea.Cmd)
+	start = func(cmd tea.Cmd) {
+		if cmd == nil {
+			return
+		}
+		go func() {
+			msg := cmd()
+			if batch, ok := msg.(tea.BatchMsg); ok {
+				for _, cmd := range batch {
+					start(cmd)
+				}
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-done:
+			}
+		}()
+	}
+	start(cmd)
+	pending := lo.Map(wants, func(want tea.Msg, _ int) reflect.Type { return reflect.TypeOf(want) })
+	for len(pending) > 0 {
+		msg := <-msgs
+		if i := slices.Index(pending, reflect.TypeOf(msg)); i >= 0 {
+			pending = slices.Delete(pending, i, i+1)
+			var next tea.Cmd
+			m, next = m.Update(msg)
+			start(next)
+		}
+	}
+	return m
+}
+
+func TestGoldenSelection(t *testing.T) {
+	backend := &fakeBackend{instances: goldenInstances()}
+	var m tea.Model = NewModel(context.Background(), backend, itn.Options{}, itn.Guardrails{})
+	h.Golden(t, "selection_loading", m.View())
+
+	m = run(m, m.Init(), spotInstancesMsg{})
+	h.Golden(t, "selection", m.View())
+
+	m = drive(m, key("/"), key("c"), key("h"), key("k"), key("enter"), key("f"))
+	h.Golden(t, "selection_filtered", m.View())
+
+	m = drive(m, key("esc"), key("s"), key("s"), key("s"), key("s"), key("k"))
+	m = send(m, key("d"), spotRequestMsg{})
+	h.Golden(t, "selection_details", m.View())
+
+	backend.err = errors.New("operation error EC2: DescribeInstances, https response error StatusCode: 403")
+	m = run(m, m.(model).Init(), errMsg{})
+	h.Golden(t, "selection_error", m.View())
+}
+
+func TestGoldenOptions(t *testing.T) {
+	instances := goldenInstances()
+	backend := &fakeBackend{
+		instances: instances,
+		experiment: &types.Experiment{
+			Id:      aws.String("EXPBCcSv1NvRNTek58"),
+			RoleArn: aws.String("arn:aws:iam::123456789012:role/aws-fis-itn"),
+			Targets: map[string]types.ExperimentTarget{"itn0": {ResourceArns: []string{"arn:aws:ec2:us-west-2:123456789012:instance/i-0a"}}},
+		},
+	}
+	var m tea.Model = NewOptions(context.Background(), backend, itn.Options{Delay: 15 * time.Second, Clean: true}, itn.Guardrails{}, nil, []*ec2types.Instance{&instances[0]})
+	h.Golden(t, "options_mode", m.View())
+
+	m = drive(m, key("enter"), key("backspace"), key("x"), key("enter"))
+	h.Golden(t, "options_invalid_delay", m.View())
+
+	m = drive(m, key("backspace"), key("backspace"), key("backspace"), key("2"), key("m"), key("enter"), key("down"), key("enter"), key("enter"))
+	m = send(m, key("enter"), accountMsg(""))
+	h.Golden(t, "options_confirm", m.View())
+
+	backend.guardrailsErr = errors.New("i-0a has the protected tag team=checkout")
+	m = send(m, key("enter"), startInterruptMsg(true))
+	h.Golden(t, "options_guardrails", m.View())
+
+	backend.guardrailsErr = nil
+	m = send(m, key("enter"), startInterruptMsg(true))
+	_, ok := m.(monitor)
+	h.Assert(t, ok, "expected the monitor, got %T", m)
+	h.Equals(t, []string{"i-0a"}, backend.interrupted)
+	h.Equals(t, 2*time.Minute, backend.opts.Delay)
+	h.Equals(t, false, backend.opts.Clean)
+}
+
+func TestGoldenMonitor(t *testing.T) {
+	backend := &fakeBackend{states: map[string]string{"i-0a": "running"}}
+	experiment := &types.Experiment{
+		Id:      aws.String("EXPBCcSv1NvRNTek58"),
+		RoleArn: aws.String("arn:aws:iam::123456789012:role/aws-fis-itn"),
+		Targets: map[string]types.ExperimentTarget{"itn0": {ResourceArns: []string{"arn:aws:ec2:us-west-2:123456789012:instance/i-0a"}}},
+	}
+	m := NewMonitor(context.Background(), backend, itn.Run{Region: "us-west-2", Experiment: experiment}, nil)
+	m.start, m.now = launched, launched
+	var model tea.Model = drive(m, statesMsg{states: map[string]string{"i-0a": "running"}})
+	model = drive(model,
+		eventMsg(itn.Event{Timestamp: launched, Type: itn.EventRebalanceRecommendation, Message: "✅ Rebalance Recommendation sent"}),
+		eventMsg(itn.Event{Timestamp: launched, Type: itn.EventInterruptionScheduled, Message: "⏳ Interruption will be sent in 2 minutes", NextEvent: 2 * time.Minute}),
+		tickMsg(launched.Add(30*time.Second)),
+	)
+	h.Golden(t, "monitor_countdown", model.View())
+
+	notified := launched.Add(2 * time.Minute)
+	model = drive(model,
+		eventMsg(itn.Event{Timestamp: notified, Type: itn.EventInterruptionNotification, Message: "✅ Spot 2-minute Interruption Notification sent", NextEvent: 2 * time.Minute}),
+		statesMsg{states: map[string]string{"i-0a": "shutting-down"}},
+		eventMsg(itn.Event{Timestamp: notified.Add(2 * time.Minute), Type: itn.EventShutdown, Message: "✅ Spot Instance Shutdown sent"}),
+		eventMsg(itn.Event{Timestamp: notified.Add(2 * time.Minute), Type: itn.EventError, Message: "❌ Experiment failed: the stop condition was triggered"}),
+		tickMsg(notified.Add(2*time.Minute)),
+		doneMsg(true),
+	)
+	h.Golden(t, "monitor_failed", model.View())
+}
+
+// Mocks
+
+type fakeBackend struct {
+	instances     []ec2types.Instance
+	states        map[string]string
+	experiment    *types.Experiment
+	err           error
+	guardrailsErr error
+	interrupted   []string
+	opts          itn.Options
+}
+
+func (f *fakeBackend) Region() string  { return "us-west-2" }
+func (f *fakeBackend) Profile() string { return "default" }
+
+func (f *fakeBackend) AccountID(ctx context.Context) (string, error) {
+	return "123456789012", nil
+}
+
+func (f *fakeBackend) SpotInstances(ctx context.Context) ([]ec2types.Instance, error) {
+	return f.instances, f.err
+}
+
+func (f *fakeBackend) SpotInstanceRequest(ctx context.Context, instance ec2types.Instance) (*ec2types.SpotInstanceRequest, error) {
+	if instance.SpotInstanceRequestId == nil {
+		return nil, nil
+	}
+	return &ec2types.SpotInstanceRequest{
+		SpotInstanceRequestId: instance.SpotInstanceRequestId,
+		State:                 ec2types.SpotInstanceStateActive,
+		Status:                &ec2types.SpotInstanceStatus{Code: aws.String("fulfilled"), Message: aws.String("Your spot request is fulfilled.")},
+		Type:                  ec2types.SpotInstanceTypeOneTime,
+		CreateTime:            aws.Time(launched),
+	}, nil
+}
+
+func (f *fakeBackend) InstanceStates(ctx context.Context, instanceIDs []string) (map[string]string, error) {
+	return f.states, nil
+}
+
+func (f *fakeBackend) CheckGuardrails(ctx context.Context, instanceIDs []string, guardrails itn.Guardrails) error {
+	return f.guardrailsErr
+}
+
+func (f *fakeBackend) Interrupt(ctx context.Context, instanceIDs []string, opts itn.Options) (*types.Experiment, <-chan itn.Event, error) {
+	f.interrupted, f.opts = instanceIDs, opts
+	return f.experiment, make(chan itn.Event), nil
+}
+
+func (f *fakeBackend) Attach(ctx context.Context, experimentID string) (*types.Experiment, <-chan itn.Event, error) {
+	return f.experiment, make(chan itn.Event), nil
+}
+
+func (f *fakeBackend) Experiments(ctx context.Context, status types.ExperimentStatus) ([]itn.Listing, error) {
+	return nil, nil
+}
+
+func (f *fakeBackend) LeftoverTemplates(ctx context.Context) ([]types.ExperimentTemplateSummary, error) {
+	return nil, nil
+}
+
+func (f *fakeBackend) Stop(ctx context.Context, experimentID string) error {
+	return fmt.Errorf("%s isn't running", experimentID)
+}
+
+func (f *fakeBackend) DeleteTemplate(ctx context.Context, templateID string) error {
+	return nil
+}
+
+func (f *fakeBackend) EventBridgeWatcher(ctx context.Context, eventBus string) itn.Watcher {
+	return func(runs []itn.Run, events <-chan itn.Event) <-chan itn.Event { return events }
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/samber/lo"
@@ -28,36 +29,65 @@ import (
 
 var helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render
 
+// tableHeight is the number of instance rows shown until the terminal size is known
+const tableHeight = 20
+
 type model struct {
-	choices     []ec2types.Instance
-	cursor      int
-	selected    map[int]*ec2types.Instance
-	ctx         context.Context
-	itn         *itn.ITN
-	initialized bool
-	spinner     spinner.Model
+	choices    []ec2types.Instance
+	rows       []int
+	cursor     int
+	offset     int
+	height     int
+	selected   map[string]bool
+	sortColumn int
+	descending bool
+	search     textinput.Model
+	searching  bool
+	// detail is the ID of the instance the detail pane is shown for, if any
+	detail       string
+	spotRequests map[string]spotRequest
+	ctx          context.Context
+	itn          Backend
+	opts         itn.Options
+	guardrails   itn.Guardrails
+	watchers     []Watchers
+	initialized  bool
+	spinner      spinner.Model
 }
 
 type spotInstancesMsg []ec2types.Instance
 type retrySpotInstances time.Time
 
-func NewModel(ctx context.Context, itn *itn.ITN) model {
+// Watchers builds the watchers of the interruptions started from the backend, so that they follow a switch of region
+// or profile
+type Watchers func(backend Backend) ([]itn.Watcher, error)
+
+func NewModel(ctx context.Context, itn Backend, opts itn.Options, guardrails itn.Guardrails, watchers ...Watchers) model {
 	sp := spinner.New()
 	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("206"))
 	sp.Spinner = spinner.Points
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search IDs, names and tags"
 	return model{
-		selected: map[int]*ec2types.Instance{},
-		ctx:      ctx,
-		itn:      itn,
-		spinner:  sp,
+		selected:     map[string]bool{},
+		spotRequests: map[string]spotRequest{},
+		height:       tableHeight,
+		search:       search,
+		ctx:          ctx,
+		itn:          itn,
+		opts:         opts,
+		guardrails:   guardrails,
+		watchers:     watchers,
+		spinner:      sp,
 	}
 }
 
-func initialModel(ctx context.Context, itn *itn.ITN) tea.Cmd {
+func initialModel(ctx context.Context, itn Backend) tea.Cmd {
 	return func() tea.Msg {
 		instances, err := itn.SpotInstances(ctx)
 		if err != nil {
-			panic(err)
+			return errMsg{err: err}
 		}
 		return spotInstancesMsg(instances)
 	}
@@ -75,18 +105,37 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case spotInstancesMsg:
 		m.choices = msg
 		m.initialized = true
+		m.refresh()
 		if len(msg) == 0 {
 			return m, tea.Every(time.Second*15, func(t time.Time) tea.Msg {
 				return retrySpotInstances(t)
 			})
 		}
+	case spotRequestMsg:
+		m.spotRequests[msg.instanceID] = msg.spotRequest
 	case retrySpotInstances:
 		return m, initialModel(m.ctx, m.itn)
+	case errMsg:
+		return errorView{
+			title: "Finding Spot instances failed",
+			err:   msg.err,
+			retry: func() (tea.Model, tea.Cmd) {
+				m.initialized = false
+				return m, m.Init()
+			},
+		}, nil
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
+	case tea.WindowSizeMsg:
+		// leave room for the header, search and help
+		m.height = max(msg.Height-8, 5)
+		m.refresh()
 	case tea.KeyMsg:
+		if m.searching {
+			return m.updateSearch(msg)
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
@@ -95,68 +144,165 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor--
 			}
 		case "down", "j":
-			if m.cursor < len(m.choices)-1 {
+			if m.cursor < len(m.rows)-1 {
 				m.cursor++
 			}
+		case "pgup":
+			m.cursor = max(m.cursor-m.height, 0)
+		case "pgdown":
+			m.cursor = max(min(m.cursor+m.height, len(m.rows)-1), 0)
 		case " ":
-			if _, ok := m.selected[m.cursor]; ok {
-				delete(m.selected, m.cursor)
-			} else {
-				m.selected[m.cursor] = &m.choices[m.cursor]
+			if len(m.rows) > 0 {
+				id := *m.choices[m.rows[m.cursor]].InstanceId
+				m.selected[id] = !m.selected[id]
+			}
+		case "a":
+			m.selectAll(lo.Range(len(m.choices)))
+		case "f":
+			m.selectAll(m.rows)
+		case "/":
+			m.searching = true
+			return m, m.search.Focus()
+		case "esc":
+			m.search.SetValue("")
+		case "s":
+			m.sortColumn = (m.sortColumn + 1) % len(columns)
+		case "S":
+			m.descending = !m.descending
+		case "d":
+			if len(m.rows) == 0 {
+				return m, nil
 			}
+			instance := m.choices[m.rows[m.cursor]]
+			if m.detail == *instance.InstanceId {
+				m.detail = ""
+				return m, nil
+			}
+			m.detail = *instance.InstanceId
+			if _, ok := m.spotRequests[m.detail]; ok {
+				return m, nil
+			}
+			return m, fetchSpotRequest(m.ctx, m.itn, instance)
+		case "R":
+			s := newSwitcher(m)
+			return s, s.Init()
+		case "tab":
+			d := newDashboard(m)
+			return d, d.Init()
 		case "enter":
-			opts := NewOptions(m.ctx, m.itn, lo.Values(m.selected))
+			instances := m.selectedInstances()
+			if len(instances) == 0 {
+				return m, nil
+			}
+			var watchers []itn.Watcher
+			for _, build := range m.watchers {
+				built, err := build(m.itn)
+				if err != nil {
+					return errorView{
+						title: "Setting up the watchers failed",
+						err:   err,
+						retry: func() (tea.Model, tea.Cmd) { return m.Update(msg) },
+						back:  m,
+					}, nil
+				}
+				watchers = append(watchers, built...)
+			}
+			opts := NewOptions(m.ctx, m.itn, m.opts, m.guardrails, watchers, instances)
+			opts.selection = m
 			return opts, opts.Init()
 		}
+		m.refresh()
 	}
 	return m, nil
 }
 
-func instanceName(i ec2types.Instance) string {
-	for _, tag := range i.Tags {
-		if *tag.Key == "Name" {
-			return *tag.Value
+// updateSearch filters the instances as the query is typed. Enter keeps the filter and esc clears it.
+func (m model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.search.SetValue("")
+		fallthrough
+	case "enter":
+		m.searching = false
+		m.search.Blur()
+		m.refresh()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.cursor = 0
+	m.refresh()
+	return m, cmd
+}
+
+// selectAll selects the instances, or deselects them if they're all selected already
+func (m *model) selectAll(rows []int) {
+	all := lo.EveryBy(rows, func(row int) bool { return m.selected[*m.choices[row].InstanceId] })
+	for _, row := range rows {
+		m.selected[*m.choices[row].InstanceId] = !all
+	}
+}
+
+func (m model) selectedInstances() []*ec2types.Instance {
+	var instances []*ec2types.Instance
+	for i := range m.choices {
+		if m.selected[*m.choices[i].InstanceId] {
+			instances = append(instances, &m.choices[i])
 		}
 	}
-	return ""
+	return instances
+}
+
+// refresh filters and sorts the rows, keeping the cursor in view
+func (m *model) refresh() {
+	m.rows = rows(m.choices, m.search.Value(), m.sortColumn, m.descending)
+	m.cursor = max(min(m.cursor, len(m.rows)-1), 0)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+	m.offset = max(min(m.offset, len(m.rows)-m.height), 0)
 }
 
 func (m model) View() string {
+	return renderTabs(0) + m.list()
+}
+
+// list renders the Spot instances to select from
+func (m model) list() string {
 	if !m.initialized {
 		return fmt.Sprintf("Finding Spot instances %s\n%s", m.spinner.View(), help())
 	}
 	if len(m.choices) == 0 {
-		return fmt.Sprintf("There are currently no Spot instances running...\nI'll keep checking though %s\n%s", m.spinner.View(), help())
+		return fmt.Sprintf("There are currently no Spot instances running...\nI'll keep checking though %s\n%s\n%s", m.spinner.View(), helpStyle(m.location()), helpStyle("\nPress R to switch region/profile or q to quit.\n"))
 	}
-	// The header
-	s := "Which Spot instances would you like to interrupt?\n\n"
-
-	// Iterate over our choices
-	for i, choice := range m.choices {
-
-		// Is the cursor pointing at this choice?
-		cursor := " " // no cursor
-		if m.cursor == i {
-			cursor = ">" // cursor!
-		}
-
-		// Is this choice selected?
-		checked := " " // not selected
-		if _, ok := m.selected[i]; ok {
-			checked = "x" // selected!
+	s := fmt.Sprintf("Which Spot instances would you like to interrupt? %d selected, showing %d of %d\n",
+		len(m.selectedInstances()), len(m.rows), len(m.choices))
+	s += helpStyle(m.location()) + "\n\n"
+	if m.searching || m.search.Value() != "" {
+		s += m.search.View() + "\n"
+	}
+	s += renderTable(m.choices, m.rows, m.cursor, m.selected, m.sortColumn, m.descending, m.offset, m.height)
+	if instance, ok := lo.Find(m.choices, func(i ec2types.Instance) bool { return *i.InstanceId == m.detail }); ok {
+		var request *spotRequest
+		if r, ok := m.spotRequests[m.detail]; ok {
+			request = &r
 		}
-
-		// Render the row
-		s += fmt.Sprintf("%s [%s] %s (%s)\n", cursor, checked, *choice.InstanceId, instanceName(choice))
+		s += "\n" + renderDetails(instance, request) + "\n"
 	}
-
-	// The footer
-	s += help()
-
-	// Send the UI for rendering
+	s += helpStyle("\n↑/↓ move • space select • a select all • f select filtered • / search • s sort • S reverse • d details • R switch region/profile • enter continue • tab experiments • q quit\n")
 	return s
 }
 
+// location is the region and profile the instances are listed from
+func (m model) location() string {
+	return fmt.Sprintf("Region: %s • Profile: %s", m.itn.Region(), lo.CoalesceOrEmpty(m.itn.Profile(), "default"))
+}
+
 func help() string {
 	return helpStyle("\nPress q to quit.\n")
 }
@@ -14,38 +14,84 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/aws/amazon-ec2-spot-interrupter/pkg/cli"
 	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
-	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
 )
 
+// statesInterval is how often the states of the interrupted instances are refreshed
+var statesInterval = 5 * time.Second
+
+// countdown is the time left until the next event of the interruption
+type countdown struct {
+	label string
+	start time.Time
+	total time.Duration
+}
+
 type monitor struct {
-	events     <-chan itn.Event
-	spinner    spinner.Model
-	experiment *types.Experiment
-	summary    string
-	eventLog   []itn.Event
+	ctx         context.Context
+	itn         Backend
+	events      <-chan itn.Event
+	spinner     spinner.Model
+	progress    progress.Model
+	summary     string
+	eventLog    []itn.Event
+	failed      bool
+	done        bool
+	start       time.Time
+	now         time.Time
+	countdown   *countdown
+	instanceIDs []string
+	states      map[string]string
+	statesErr   error
+	// back is the screen to go back to when the monitor was attached from the dashboard, and detach stops following
+	// the experiment when going back
+	back   tea.Model
+	detach context.CancelFunc
 }
 
-func NewMonitor(experiment *types.Experiment, events <-chan itn.Event) monitor {
+// NewMonitor follows the events of the run, refreshing the states of the instances it targets. The TUI interrupts
+// instances in the region of its backend only, so a monitor follows a single run.
+func NewMonitor(ctx context.Context, i Backend, run itn.Run, events <-chan itn.Event) monitor {
 	sp := spinner.New()
 	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("206"))
 	sp.Spinner = spinner.Points
+	now := time.Now()
+	// experiments of persistent templates select the instances by tags rather than ARN
+	instanceIDs := lo.Uniq(lo.CoalesceSliceOrEmpty(run.InstanceIDs, itn.ExperimentInstanceIDs(*run.Experiment)))
+	sort.Strings(instanceIDs)
 	return monitor{
-		experiment: experiment,
-		summary:    cli.Summary(experiment),
-		events:     events,
-		spinner:    sp,
+		ctx:         ctx,
+		itn:         i,
+		summary:     cli.Summary(run.Experiment),
+		events:      events,
+		spinner:     sp,
+		progress:    progress.New(progress.WithDefaultGradient(), progress.WithWidth(40)),
+		start:       now,
+		now:         now,
+		instanceIDs: instanceIDs,
+		states:      map[string]string{},
 	}
 }
 
 type eventMsg itn.Event
 type doneMsg bool
+type tickMsg time.Time
+type statesMsg struct {
+	states map[string]string
+	err    error
+}
+type refreshStatesMsg bool
 
 func eventListener(events <-chan itn.Event) tea.Cmd {
 	return func() tea.Msg {
@@ -57,8 +103,22 @@ func eventListener(events <-chan itn.Event) tea.Cmd {
 	}
 }
 
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m monitor) fetchStates() tea.Cmd {
+	if len(m.instanceIDs) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		states, err := m.itn.InstanceStates(m.ctx, m.instanceIDs)
+		return statesMsg{states: states, err: err}
+	}
+}
+
 func (m monitor) Init() tea.Cmd {
-	return tea.Batch(spinner.Tick, eventListener(m.events))
+	return tea.Batch(spinner.Tick, eventListener(m.events), tick(), m.fetchStates())
 }
 
 func (m monitor) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -67,10 +127,43 @@ func (m monitor) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
+	case tickMsg:
+		m.now = time.Time(msg)
+		if m.done {
+			return m, nil
+		}
+		return m, tick()
+	case statesMsg:
+		m.statesErr = msg.err
+		if msg.err == nil {
+			m.states = msg.states
+		}
+		if m.done {
+			return m, nil
+		}
+		return m, tea.Tick(statesInterval, func(time.Time) tea.Msg { return refreshStatesMsg(true) })
+	case refreshStatesMsg:
+		return m, m.fetchStates()
 	case eventMsg:
-		m.eventLog = append(m.eventLog, itn.Event(msg))
+		event := itn.Event(msg)
+		m.eventLog = append(m.eventLog, event)
+		m.failed = m.failed || event.Type == itn.EventError
+		switch {
+		case event.NextEvent > 0 && event.Type == itn.EventInterruptionScheduled:
+			m.countdown = &countdown{label: "until the interruption notification", start: event.Timestamp, total: event.NextEvent}
+		case event.NextEvent > 0 && event.Type == itn.EventInterruptionNotification:
+			m.countdown = &countdown{label: "until shutdown", start: event.Timestamp, total: event.NextEvent}
+		case event.Type == itn.EventShutdown:
+			m.countdown = nil
+		}
 		return m, eventListener(m.events)
 	case doneMsg:
+		m.done = true
+		m.countdown = nil
+		// stay open on failure so the errors can be read, and to go back to the dashboard
+		if m.failed || m.back != nil {
+			return m, nil
+		}
 		return m, tea.Quit
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -78,6 +171,17 @@ func (m monitor) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		case "enter":
 			return m, tea.Quit
+		case "b", "esc":
+			if m.back == nil {
+				return m, nil
+			}
+			m.detach()
+			// the experiment's monitoring stops with the context, drain what it still sends
+			go func() {
+				for range m.events {
+				}
+			}()
+			return m.back, m.back.Init()
 		}
 	}
 	return m, nil
@@ -85,10 +189,36 @@ func (m monitor) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m monitor) View() string {
 	s := fmt.Sprintf("%s\n", m.summary)
+	s += fmt.Sprintf("Elapsed: %s\n", m.now.Sub(m.start).Round(time.Second))
+	if c := m.countdown; c != nil {
+		elapsed := min(max(m.now.Sub(c.start), 0), c.total)
+		s += fmt.Sprintf("⏳ %s %s\n%s\n", (c.total - elapsed).Round(time.Second), c.label, m.progress.ViewAs(float64(elapsed)/float64(c.total)))
+	}
+	if len(m.states) > 0 || m.statesErr != nil {
+		s += "\n"
+		for _, instanceID := range m.instanceIDs {
+			s += fmt.Sprintf("  %-20s %s\n", instanceID, lo.ValueOr(m.states, instanceID, "unknown"))
+		}
+		if m.statesErr != nil {
+			s += errorStyle.Render(fmt.Sprintf("  refreshing the instance states failed: %s", m.statesErr)) + "\n"
+		}
+	}
+	s += "\n"
 	for _, event := range m.eventLog {
-		s += fmt.Sprintf("%s\n", event.Message)
+		line := event.Timestamp.Format("15:04:05") + " "
+		if event.Type == itn.EventError {
+			s += errorStyle.Render(line+event.Message) + "\n"
+			continue
+		}
+		s += line + event.Message + "\n"
+	}
+	if m.done && m.failed {
+		s += errorStyle.Render("\nThe interruption failed.")
+	} else if !m.done {
+		s += m.spinner.View()
+	}
+	if m.back != nil {
+		return s + helpStyle("\nPress b to go back or q to quit.\n")
 	}
-	s += m.spinner.View()
-	s += help()
-	return s
+	return s + help()
 }
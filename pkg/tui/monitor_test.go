@@ -0,0 +1,64 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/fis/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMonitorCountdown(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	experiment := &types.Experiment{Id: aws.String("EXP1"), RoleArn: aws.String("arn:aws:iam::123456789012:role/aws-fis-itn")}
+	m := NewMonitor(context.Background(), &fakeBackend{}, itn.Run{Region: "us-west-2", Experiment: experiment}, nil)
+	m.start = start
+	var model tea.Model = m
+	model, _ = model.Update(eventMsg(itn.Event{Type: itn.EventInterruptionScheduled, Message: "⏳ Interruption in 2m", Timestamp: start, NextEvent: 2 * time.Minute}))
+	model, _ = model.Update(tickMsg(start.Add(30 * time.Second)))
+	view := model.View()
+	for _, expected := range []string{"Elapsed: 30s", "⏳ 1m30s until the interruption notification", "12:00:00 ⏳ Interruption in 2m"} {
+		h.Assert(t, strings.Contains(view, expected), "expected %q in %q", expected, view)
+	}
+
+	notified := start.Add(2 * time.Minute)
+	model, _ = model.Update(eventMsg(itn.Event{Type: itn.EventInterruptionNotification, Message: "✅ Interruption notification sent", Timestamp: notified, NextEvent: 2 * time.Minute}))
+	model, _ = model.Update(tickMsg(notified.Add(time.Minute)))
+	h.Assert(t, strings.Contains(model.View(), "⏳ 1m0s until shutdown"), "expected the shutdown countdown in %q", model.View())
+
+	model, _ = model.Update(eventMsg(itn.Event{Type: itn.EventShutdown, Message: "✅ Instance shut down", Timestamp: notified.Add(2 * time.Minute)}))
+	h.Assert(t, !strings.Contains(model.View(), "until shutdown"), "expected no countdown after the shutdown in %q", model.View())
+}
+
+func TestMonitorInstanceStates(t *testing.T) {
+	m := monitor{itn: &fakeBackend{}, instanceIDs: []string{"i-1", "i-2"}}
+	var model tea.Model = m
+	model, cmd := model.Update(statesMsg{states: map[string]string{"i-1": "running"}})
+	h.Assert(t, cmd != nil, "expected the states to be refreshed again")
+	view := model.View()
+	h.Assert(t, strings.Contains(view, "i-1") && strings.Contains(view, "running"), "expected the state of i-1 in %q", view)
+	h.Assert(t, strings.Contains(view, "unknown"), "expected i-2 to be unknown in %q", view)
+
+	// polling stops with the interruption
+	model, _ = model.Update(doneMsg(true))
+	_, cmd = model.Update(statesMsg{states: map[string]string{"i-1": "terminated"}})
+	h.Assert(t, cmd == nil, "expected the states to stop being refreshed")
+}
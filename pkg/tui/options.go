@@ -15,39 +15,84 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/cli"
 	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
 )
 
+// step is a step of the wizard that sets up the interruption
+type step int
+
+const (
+	stepMode step = iota
+	stepDelay
+	stepClean
+	stepStopAlarms
+	stepRole
+	stepConfirm
+)
+
+var activeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("206"))
+
 type options struct {
 	instances      []*ec2types.Instance
 	ctx            context.Context
-	itn            *itn.ITN
-	textInput      textinput.Model
+	itn            Backend
+	opts           itn.Options
+	guardrails     itn.Guardrails
+	watchers       []itn.Watcher
+	step           step
+	modeCursor     int
+	delayInput     textinput.Model
+	alarmsInput    textinput.Model
+	roleInput      textinput.Model
+	accountID      string
 	validationMsg  string
 	processingOpts bool
+	// selection is the instance selection to go back to from the first step
+	selection tea.Model
 }
 
-func NewOptions(ctx context.Context, itn *itn.ITN, instances []*ec2types.Instance) options {
-	ti := textinput.New()
-	ti.SetValue("15s")
-	ti.Focus()
-	ti.CharLimit = 20
-	ti.Width = 20
+func NewOptions(ctx context.Context, itn Backend, opts itn.Options, guardrails itn.Guardrails, watchers []itn.Watcher, instances []*ec2types.Instance) options {
+	input := func(value string, placeholder string, width int) textinput.Model {
+		ti := textinput.New()
+		ti.SetValue(value)
+		ti.Placeholder = placeholder
+		ti.Width = width
+		return ti
+	}
+	delayInput := input(opts.Delay.String(), "1m", 20)
+	delayInput.CharLimit = 20
 	return options{
-		ctx:       ctx,
-		itn:       itn,
-		instances: instances,
-		textInput: ti,
+		ctx:         ctx,
+		itn:         itn,
+		opts:        opts,
+		guardrails:  guardrails,
+		watchers:    watchers,
+		instances:   instances,
+		modeCursor:  modeIndex(opts.Mode),
+		delayInput:  delayInput,
+		alarmsInput: input(strings.Join(opts.StopAlarms, ","), "none, or comma-separated CloudWatch alarm ARNs", 80),
+		roleInput:   input(opts.RoleARN, "none, the interrupter creates or reuses its own role", 80),
 	}
 }
 
+// modeIndex is the index of the mode in the choices, defaulting to the first
+func modeIndex(mode itn.Mode) int {
+	return max(lo.IndexOf(itn.Modes, mode), 0)
+}
+
 type startInterruptMsg bool
+type accountMsg string
 
 func (o options) Init() tea.Cmd {
 	return textinput.Blink
@@ -56,56 +101,210 @@ func (o options) Init() tea.Cmd {
 func (o options) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case accountMsg:
+		o.accountID = string(msg)
+		return o, nil
 	case startInterruptMsg:
-		var instanceIDs []string
-		for _, instance := range o.instances {
-			instanceIDs = append(instanceIDs, *instance.InstanceId)
+		instanceIDs := o.instanceIDs()
+		if err := o.itn.CheckGuardrails(o.ctx, instanceIDs, o.guardrails); err != nil {
+			o.processingOpts = false
+			o.validationMsg = fmt.Sprintf("❌ %s", err)
+			return o, nil
 		}
-		delay, err := o.validateDelay()
+		experiment, events, err := o.itn.Interrupt(o.ctx, instanceIDs, o.opts)
 		if err != nil {
-			o.textInput, cmd = o.textInput.Update(msg)
-			return o, cmd
-		}
-		experiment, events, err := o.itn.Interrupt(o.ctx, instanceIDs, delay, true)
-		if err != nil {
-			fmt.Printf("❌ %s\n", err)
-			return o, tea.Quit
+			var validationErr *itn.ValidationError
+			if errors.As(err, &validationErr) {
+				o.processingOpts = false
+				o.validationMsg = fmt.Sprintf("❌ %d instances failed validation:\n%s", len(validationErr.Invalid), cli.ValidationTable(validationErr))
+				return o, nil
+			}
+			o.processingOpts = false
+			return errorView{
+				title: "Starting the interruption failed",
+				err:   err,
+				retry: func() (tea.Model, tea.Cmd) {
+					return o.next()
+				},
+				back: o.selection,
+			}, nil
 		}
-		monitor := NewMonitor(experiment, events)
+		run := itn.Run{Region: o.itn.Region(), Experiment: experiment, InstanceIDs: instanceIDs}
+		monitor := NewMonitor(o.ctx, o.itn, run, itn.Watch([]itn.Run{run}, events, o.watchers...))
 		return monitor, monitor.Init()
 	case tea.KeyMsg:
+		if o.processingOpts {
+			return o, nil
+		}
 		switch msg.String() {
-		case "ctrl+c", "q":
+		case "ctrl+c":
 			return o, tea.Quit
-		case "enter":
-			o.processingOpts = true
-			return o, func() tea.Msg {
-				return startInterruptMsg(true)
+		case "q":
+			if o.input() == nil {
+				return o, tea.Quit
 			}
+		case "esc", "shift+tab":
+			return o.back()
+		case "enter", "tab":
+			return o.next()
+		case "up", "k", "left", "h":
+			o.choose(-1)
+		case "down", "j", "right", "l":
+			o.choose(1)
 		}
 	}
-	o.textInput, cmd = o.textInput.Update(msg)
+	if input := o.input(); input != nil {
+		*input, cmd = input.Update(msg)
+	}
 	return o, cmd
 }
 
-func (o *options) validateDelay() (time.Duration, error) {
-	delay, err := time.ParseDuration(o.textInput.Value())
-	if err != nil {
-		o.validationMsg = "❌ invalid duration format (example: 1m)"
-		return delay, fmt.Errorf("invalid duration format: %v", err)
+// input is the text input of the step, if it has one
+func (o *options) input() *textinput.Model {
+	switch o.step {
+	case stepDelay:
+		return &o.delayInput
+	case stepStopAlarms:
+		return &o.alarmsInput
+	case stepRole:
+		return &o.roleInput
+	}
+	return nil
+}
+
+// choose moves between the choices of the mode and clean steps
+func (o *options) choose(delta int) {
+	switch o.step {
+	case stepMode:
+		o.modeCursor = (o.modeCursor + delta + len(itn.Modes)) % len(itn.Modes)
+	case stepClean:
+		o.opts.Clean = !o.opts.Clean
+	}
+}
+
+// next applies the step and moves on to the next one, or starts the interruption once it's confirmed
+func (o options) next() (tea.Model, tea.Cmd) {
+	if err := o.apply(); err != nil {
+		o.validationMsg = fmt.Sprintf("❌ %s", err)
+		return o, nil
+	}
+	o.validationMsg = ""
+	if o.step == stepConfirm {
+		o.processingOpts = true
+		return o, func() tea.Msg {
+			return startInterruptMsg(true)
+		}
+	}
+	return o.goTo(o.step + 1)
+}
+
+// back returns to the previous step, or to the instance selection from the first one
+func (o options) back() (tea.Model, tea.Cmd) {
+	o.validationMsg = ""
+	if o.step == stepMode {
+		if o.selection == nil {
+			return o, nil
+		}
+		return o.selection, nil
+	}
+	return o.goTo(o.step - 1)
+}
+
+func (o options) goTo(s step) (tea.Model, tea.Cmd) {
+	if input := o.input(); input != nil {
+		input.Blur()
+	}
+	o.step = s
+	var cmds []tea.Cmd
+	if input := o.input(); input != nil {
+		cmds = append(cmds, input.Focus())
+	}
+	if s == stepConfirm && o.accountID == "" {
+		cmds = append(cmds, o.fetchAccountID())
+	}
+	return o, tea.Batch(cmds...)
+}
+
+func (o options) fetchAccountID() tea.Cmd {
+	return func() tea.Msg {
+		accountID, err := o.itn.AccountID(o.ctx)
+		if err != nil {
+			return accountMsg(fmt.Sprintf("unknown (%s)", err))
+		}
+		return accountMsg(accountID)
+	}
+}
+
+// apply validates the input of the step and sets its option
+func (o *options) apply() error {
+	switch o.step {
+	case stepMode:
+		mode := itn.Modes[o.modeCursor]
+		if mode == itn.ModePersistent && (len(o.opts.Tags) == 0 || o.opts.TemplateName == "") {
+			return errors.New("persistent mode targets --tags with a --template-name, pass them to use it")
+		}
+		o.opts.Mode = mode
+	case stepDelay:
+		delay, err := time.ParseDuration(o.delayInput.Value())
+		if err != nil {
+			return errors.New("invalid duration format (example: 1m)")
+		}
+		o.opts.Delay = delay
+	case stepStopAlarms:
+		o.opts.StopAlarms = splitList(o.alarmsInput.Value())
+		for _, alarm := range o.opts.StopAlarms {
+			if !strings.HasPrefix(alarm, "arn:") {
+				return fmt.Errorf("%q is not an alarm ARN", alarm)
+			}
+		}
+	case stepRole:
+		o.opts.RoleARN = strings.TrimSpace(o.roleInput.Value())
+		if o.opts.RoleARN != "" && !strings.HasPrefix(o.opts.RoleARN, "arn:") {
+			return fmt.Errorf("%q is not a role ARN", o.opts.RoleARN)
+		}
 	}
-	o.validationMsg = "✅"
-	return delay, err
+	return nil
+}
+
+func splitList(value string) []string {
+	return lo.Compact(lo.Map(strings.Split(value, ","), func(item string, _ int) string { return strings.TrimSpace(item) }))
+}
+
+func (o options) instanceIDs() []string {
+	return lo.Map(o.instances, func(instance *ec2types.Instance, _ int) string { return *instance.InstanceId })
 }
 
 func (o options) View() string {
 	if o.processingOpts {
 		return fmt.Sprintf("Creating Interruption Experiment \n%s", help())
 	}
-	return fmt.Sprintf(
-		"How long to wait before sending the interruption notifications?\n%s\n%s\n%s",
-		o.textInput.View(),
-		o.validationMsg,
-		help(),
-	)
+	var s string
+	switch o.step {
+	case stepMode:
+		s = "How should the experiment template be managed?\n"
+		for i, mode := range itn.Modes {
+			line := fmt.Sprintf("( ) %s", mode)
+			if i == o.modeCursor {
+				line = activeStyle.Render(fmt.Sprintf("(•) %s", mode))
+			}
+			s += line + "\n"
+		}
+	case stepDelay:
+		s = fmt.Sprintf("How long to wait before sending the interruption notifications?\n%s\n", o.delayInput.View())
+	case stepClean:
+		s = "Clean up the experiment template once it's done?\n"
+		s += lo.Ternary(o.opts.Clean, activeStyle.Render("(•) yes")+"\n( ) no\n", "( ) yes\n"+activeStyle.Render("(•) no")+"\n")
+	case stepStopAlarms:
+		s = fmt.Sprintf("Stop the experiment when any of these CloudWatch alarms is in ALARM?\n%s\n", o.alarmsInput.View())
+	case stepRole:
+		s = fmt.Sprintf("Which IAM role should FIS use?\n%s\n", o.roleInput.View())
+	case stepConfirm:
+		accountID := lo.Ternary(o.accountID == "", "…", o.accountID)
+		s = fmt.Sprintf("About to interrupt:\n%s", cli.PlanSummary(accountID, o.itn.Region(), o.instanceIDs(), o.opts))
+	}
+	if o.validationMsg != "" {
+		s += o.validationMsg + "\n"
+	}
+	next := lo.Ternary(o.step == stepConfirm, "enter interrupt", "enter next")
+	return s + helpStyle(fmt.Sprintf("\nStep %d of %d • %s • esc back • ctrl+c quit\n", o.step+1, stepConfirm+1, next))
 }
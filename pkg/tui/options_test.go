@@ -0,0 +1,90 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func key(k string) tea.KeyMsg {
+	switch k {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+func TestWizard(t *testing.T) {
+	instance := instance("i-0a", "web", ec2types.InstanceTypeM5Large, time.Now())
+	interrupter := itn.New(aws.Config{Region: "us-west-2"})
+	selection := NewModel(context.Background(), interrupter, itn.Options{}, itn.Guardrails{})
+	wizard := NewOptions(context.Background(), interrupter, itn.Options{Delay: 15 * time.Second, Clean: true}, itn.Guardrails{}, nil, []*ec2types.Instance{&instance})
+	wizard.selection = selection
+	var m tea.Model = wizard
+	press := func(keys ...string) {
+		for _, k := range keys {
+			m, _ = m.Update(key(k))
+		}
+	}
+
+	// persistent mode needs --tags
+	press("down", "enter")
+	h.Equals(t, stepMode, m.(options).step)
+	h.Assert(t, strings.Contains(m.View(), "persistent mode targets --tags"), "expected a validation error, got %q", m.View())
+	press("down", "enter")
+	h.Equals(t, stepDelay, m.(options).step)
+
+	// an invalid delay stays on the step until it's fixed
+	press("backspace", "backspace", "backspace", "x", "enter")
+	h.Equals(t, stepDelay, m.(options).step)
+	press("backspace", "1", "m", "enter")
+	h.Equals(t, stepClean, m.(options).step)
+	press("down", "enter", "enter", "esc", "enter")
+	h.Equals(t, stepRole, m.(options).step)
+	press("enter")
+	m, _ = m.Update(accountMsg("12345"))
+
+	o := m.(options)
+	h.Equals(t, stepConfirm, o.step)
+	h.Equals(t, time.Minute, o.opts.Delay)
+	h.Equals(t, false, o.opts.Clean)
+	view := o.View()
+	for _, expected := range []string{"Account: 12345", "Region: us-west-2", "Delay: 1m0s", "Clean: false", "- i-0a"} {
+		h.Assert(t, strings.Contains(view, expected), "expected %q in the confirmation, got %q", expected, view)
+	}
+
+	// backing out of the first step returns to the instance selection
+	press("esc", "esc", "esc", "esc", "esc", "esc")
+	_, ok := m.(model)
+	h.Assert(t, ok, "expected to be back at the instance selection, got %T", m)
+}
@@ -0,0 +1,114 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// connect builds the ITN for the region and profile switched to
+var connect = func(ctx context.Context, region string, profile string) (Backend, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region), awsconfig.WithSharedConfigProfile(profile))
+	if err != nil {
+		return nil, err
+	}
+	return itn.New(cfg), nil
+}
+
+// switcher switches the region and profile the Spot instances are listed from
+type switcher struct {
+	selection model
+	inputs    []textinput.Model
+	focus     int
+	err       error
+}
+
+type connectedMsg struct{ itn Backend }
+type connectErrMsg struct{ err error }
+
+func newSwitcher(selection model) switcher {
+	region, profile := "", ""
+	if selection.itn != nil {
+		region, profile = selection.itn.Region(), selection.itn.Profile()
+	}
+	inputs := []textinput.Model{textinput.New(), textinput.New()}
+	inputs[0].Prompt = "Region:  "
+	inputs[0].SetValue(region)
+	inputs[1].Prompt = "Profile: "
+	inputs[1].Placeholder = "default"
+	inputs[1].SetValue(profile)
+	inputs[0].Focus()
+	return switcher{selection: selection, inputs: inputs}
+}
+
+func (s switcher) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (s switcher) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case connectedMsg:
+		m := NewModel(s.selection.ctx, msg.itn, s.selection.opts, s.selection.guardrails, s.selection.watchers...)
+		m.height = s.selection.height
+		return m, m.Init()
+	case connectErrMsg:
+		s.err = msg.err
+		return s, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return s, tea.Quit
+		case "esc":
+			return s.selection, nil
+		case "tab", "shift+tab", "up", "down":
+			s.inputs[s.focus].Blur()
+			s.focus = (s.focus + 1) % len(s.inputs)
+			return s, s.inputs[s.focus].Focus()
+		case "enter":
+			if s.inputs[0].Value() == "" {
+				s.err = fmt.Errorf("a region is required")
+				return s, nil
+			}
+			s.err = nil
+			ctx, region, profile := s.selection.ctx, s.inputs[0].Value(), s.inputs[1].Value()
+			return s, func() tea.Msg {
+				i, err := connect(ctx, region, profile)
+				if err != nil {
+					return connectErrMsg{err: err}
+				}
+				return connectedMsg{itn: i}
+			}
+		}
+	}
+	var cmd tea.Cmd
+	s.inputs[s.focus], cmd = s.inputs[s.focus].Update(msg)
+	return s, cmd
+}
+
+func (s switcher) View() string {
+	v := "Switch the region and profile to list Spot instances from\n\n"
+	for _, input := range s.inputs {
+		v += input.View() + "\n"
+	}
+	if s.err != nil {
+		v += "\n" + errorStyle.Render(fmt.Sprintf("❌ %s", s.err)) + "\n"
+	}
+	return v + helpStyle("\ntab next field • enter switch • esc back\n")
+}
@@ -0,0 +1,172 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+)
+
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
+	cursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("206"))
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+)
+
+// maxColumnWidth truncates long values, like tags, so the table fits in a terminal
+const maxColumnWidth = 40
+
+// column is a column of the instance table
+type column struct {
+	title string
+	value func(ec2types.Instance) string
+	// less orders the column when sorted, comparing the values if it's nil
+	less func(a, b ec2types.Instance) bool
+}
+
+var columns = []column{
+	{title: "INSTANCE ID", value: func(i ec2types.Instance) string { return *i.InstanceId }},
+	{title: "NAME", value: instanceName},
+	{title: "TYPE", value: func(i ec2types.Instance) string { return string(i.InstanceType) }},
+	{title: "AZ", value: func(i ec2types.Instance) string {
+		if i.Placement == nil {
+			return ""
+		}
+		return lo.FromPtr(i.Placement.AvailabilityZone)
+	}},
+	{
+		title: "LAUNCHED",
+		value: func(i ec2types.Instance) string {
+			if i.LaunchTime == nil {
+				return ""
+			}
+			return i.LaunchTime.Format("2006-01-02T15:04:05")
+		},
+		less: func(a, b ec2types.Instance) bool { return lo.FromPtr(a.LaunchTime).Before(lo.FromPtr(b.LaunchTime)) },
+	},
+	{title: "ASG", value: func(i ec2types.Instance) string { return tagValue(i, "aws:autoscaling:groupName") }},
+	{title: "PRIVATE IP", value: func(i ec2types.Instance) string { return lo.FromPtr(i.PrivateIpAddress) }},
+	{title: "TAGS", value: keyTags},
+}
+
+func instanceName(i ec2types.Instance) string {
+	return tagValue(i, "Name")
+}
+
+func tagValue(i ec2types.Instance, key string) string {
+	tag, _ := lo.Find(i.Tags, func(tag ec2types.Tag) bool { return lo.FromPtr(tag.Key) == key })
+	return lo.FromPtr(tag.Value)
+}
+
+// keyTags are the tags users set, skipping the Name and the ones AWS sets
+func keyTags(i ec2types.Instance) string {
+	tags := lo.FilterMap(i.Tags, func(tag ec2types.Tag, _ int) (string, bool) {
+		key := lo.FromPtr(tag.Key)
+		return fmt.Sprintf("%s=%s", key, lo.FromPtr(tag.Value)), key != "Name" && !strings.HasPrefix(key, "aws:")
+	})
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
+
+// fuzzyMatch reports whether the characters of the query appear in order in the text, ignoring case
+func fuzzyMatch(query string, text string) bool {
+	text = strings.ToLower(text)
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(text, r)
+		if i < 0 {
+			return false
+		}
+		text = text[i+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+// searchFields are what the search matches an instance by, its ID, name and tag keys and values. Matching them
+// separately keeps the characters of a query from being scattered across unrelated fields.
+func searchFields(i ec2types.Instance) []string {
+	fields := []string{*i.InstanceId, instanceName(i)}
+	for _, tag := range i.Tags {
+		fields = append(fields, lo.FromPtr(tag.Key), lo.FromPtr(tag.Value))
+	}
+	return fields
+}
+
+// rows returns the indices of the instances matching the query, ordered by the column
+func rows(instances []ec2types.Instance, query string, sortColumn int, descending bool) []int {
+	rows := lo.Filter(lo.Range(len(instances)), func(i int, _ int) bool {
+		return lo.ContainsBy(searchFields(instances[i]), func(field string) bool { return fuzzyMatch(query, field) })
+	})
+	col := columns[sortColumn]
+	less := col.less
+	if less == nil {
+		less = func(a, b ec2types.Instance) bool { return col.value(a) < col.value(b) }
+	}
+	sort.SliceStable(rows, func(a, b int) bool {
+		if descending {
+			return less(instances[rows[b]], instances[rows[a]])
+		}
+		return less(instances[rows[a]], instances[rows[b]])
+	})
+	return rows
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// renderTable renders the rows in view, marking the cursor, the selected instances and the sorted column
+func renderTable(instances []ec2types.Instance, rows []int, cursor int, selected map[string]bool, sortColumn int, descending bool, offset int, height int) string {
+	visible := rows[offset:min(offset+height, len(rows))]
+	cells := lo.Map(visible, func(row int, _ int) []string {
+		return lo.Map(columns, func(col column, _ int) string { return truncate(col.value(instances[row]), maxColumnWidth) })
+	})
+	titles := lo.Map(columns, func(col column, c int) string {
+		if c != sortColumn {
+			return col.title
+		}
+		return col.title + lo.Ternary(descending, " ▼", " ▲")
+	})
+	widths := lo.Map(titles, func(title string, c int) int {
+		return lo.Max(append(lo.Map(cells, func(row []string, _ int) int { return utf8.RuneCountInString(row[c]) }), utf8.RuneCountInString(title)))
+	})
+	pad := func(values []string) string {
+		return strings.TrimRight(strings.Join(lo.Map(values, func(v string, c int) string {
+			return v + strings.Repeat(" ", widths[c]-utf8.RuneCountInString(v))
+		}), "  "), " ")
+	}
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("      "+pad(titles)) + "\n")
+	for i, row := range visible {
+		pointer := lo.Ternary(offset+i == cursor, ">", " ")
+		checked := lo.Ternary(selected[*instances[row].InstanceId], "x", " ")
+		line := fmt.Sprintf("%s [%s] %s", pointer, checked, pad(cells[i]))
+		switch {
+		case offset+i == cursor:
+			line = cursorStyle.Render(line)
+		case selected[*instances[row].InstanceId]:
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ec2-spot-interrupter/pkg/itn"
+	h "github.com/aws/amazon-ec2-spot-interrupter/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+)
+
+func instance(id string, name string, instanceType ec2types.InstanceType, launched time.Time, tags ...string) ec2types.Instance {
+	instance := ec2types.Instance{
+		InstanceId:   aws.String(id),
+		InstanceType: instanceType,
+		LaunchTime:   aws.Time(launched),
+		Tags:         []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String(name)}},
+	}
+	for i := 0; i < len(tags); i += 2 {
+		instance.Tags = append(instance.Tags, ec2types.Tag{Key: aws.String(tags[i]), Value: aws.String(tags[i+1])})
+	}
+	return instance
+}
+
+func testInstances() []ec2types.Instance {
+	now := time.Now()
+	return []ec2types.Instance{
+		instance("i-0a", "web", ec2types.InstanceTypeM5Large, now.Add(-time.Hour), "team", "checkout"),
+		instance("i-0b", "batch", ec2types.InstanceTypeC5Xlarge, now.Add(-3*time.Hour), "team", "data", "aws:autoscaling:groupName", "batch-asg"),
+		instance("i-0c", "web", ec2types.InstanceTypeM5Large, now.Add(-2*time.Hour), "team", "search"),
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	h.Assert(t, fuzzyMatch("", "anything"), "empty queries match everything")
+	h.Assert(t, fuzzyMatch("chk", "checkout"), "subsequences match")
+	h.Assert(t, fuzzyMatch("WEB", "web"), "matching ignores case")
+	h.Assert(t, !fuzzyMatch("kc", "checkout"), "characters match in order")
+}
+
+func TestRows(t *testing.T) {
+	instances := testInstances()
+	h.Equals(t, []int{0, 1, 2}, rows(instances, "", 0, false))
+	h.Equals(t, []int{2, 1, 0}, rows(instances, "", 0, true))
+	// launch time sorts chronologically
+	h.Equals(t, []int{1, 2, 0}, rows(instances, "", 4, false))
+	h.Equals(t, []int{0, 2}, rows(instances, "web", 0, false))
+	h.Equals(t, []int{1}, rows(instances, "dat", 0, false))
+	h.Equals(t, "team=data", keyTags(instances[1]))
+}
+
+func TestModelSelection(t *testing.T) {
+	var m tea.Model = NewModel(context.Background(), &fakeBackend{}, itn.Options{}, itn.Guardrails{})
+	m, _ = m.Update(spotInstancesMsg(testInstances()))
+	press := func(keys ...string) {
+		for _, k := range keys {
+			m, _ = m.Update(key(k))
+		}
+	}
+	selectedIDs := func() []string {
+		return lo.Map(m.(model).selectedInstances(), func(i *ec2types.Instance, _ int) string { return *i.InstanceId })
+	}
+
+	// select the filtered web instances, then the batch instance under the cursor once the filter is cleared
+	press("/", "w", "e", "b", "enter", "f")
+	h.Equals(t, []string{"i-0a", "i-0c"}, selectedIDs())
+	press("esc", "j", " ")
+	h.Equals(t, []string{"i-0a", "i-0b", "i-0c"}, selectedIDs())
+	press("a")
+	h.Equals(t, 0, len(selectedIDs()))
+}
@@ -66,7 +66,7 @@ func TestSpotITN(t *testing.T) {
 	// Run spot-interrupter with created Spot instance
 	fmt.Printf("Starting spot-interrupter with instance %s ...\n", spotInstanceID)
 	spotItnCommand := exec.Command(APP_PATH,
-		"--instance-ids", spotInstanceID, "--region", TEST_REGION)
+		"--instance-ids", spotInstanceID, "--region", TEST_REGION, "--yes")
 	spotiOutput, err := spotItnCommand.Output()
 	require.Nil(t, err)
 	spotiOutputClean := string(spotiOutput)